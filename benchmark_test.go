@@ -0,0 +1,69 @@
+package pivot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func benchmarkData(records int) [][]interface{} {
+	data := [][]interface{}{{"A", "B", "C", "D", "V1", "V2"}}
+	for i := 0; i < records; i++ {
+		data = append(data, []interface{}{
+			fmt.Sprintf("A%d", i%10),
+			fmt.Sprintf("B%d", i%20),
+			fmt.Sprintf("C%d", i%12),
+			fmt.Sprintf("D%d", i%4),
+			i % 100,
+			fmt.Sprintf("%d,5", i%7+1),
+		})
+	}
+	return data
+}
+
+func benchmarkTable(data [][]interface{}) *Table[float64] {
+	return NewTable(data, true).
+		Row(0).
+		Row(1).
+		Column(2).
+		Column(3).
+		Values(4, Sum, Digits(0)).
+		ComputedValues("V1/V2", DataRefs([]int{4, 5}, Sum), Ratio[float64](ZeroAsNaN), Digits(2)).
+		ComputedValues("Avg", []DataRef{Weighted(4, 5), Weights(4, 5)}, WeightedAverage[float64](ZeroAsNaN), Digits(2))
+}
+
+func benchmarkGenerate(b *testing.B, records int, options ...GenerateOption) {
+	data := benchmarkData(records)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := benchmarkTable(data).Generate(options...)
+		if err != nil {
+			b.Fatalf("%s", err)
+		}
+	}
+}
+
+func BenchmarkGenerate1K(b *testing.B) {
+	benchmarkGenerate(b, 1000)
+}
+
+func BenchmarkGenerate100K(b *testing.B) {
+	benchmarkGenerate(b, 100000)
+}
+
+func BenchmarkGenerate100KParallel(b *testing.B) {
+	benchmarkGenerate(b, 100000, WithWorkers(4))
+}
+
+func BenchmarkGenerateHighCardinality(b *testing.B) {
+	data := benchmarkData(100000)
+	for i, record := range data[1:] {
+		record[1] = fmt.Sprintf("B%d", i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := benchmarkTable(data).Generate()
+		if err != nil {
+			b.Fatalf("%s", err)
+		}
+	}
+}
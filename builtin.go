@@ -3,9 +3,11 @@ package pivot
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var AlphaSort Sort = func(elements []Header) []Header {
@@ -57,34 +59,224 @@ var Group = func(groups [][]string, groupLabels []string, noneLabel string) Comp
 	}
 }
 
-var SumFloats Compute[float64] = func(elements []RawValue) (float64, error) {
-	var result float64
+var SumFloats Compute[float64] = SumOf[float64]
+
+var SumDecimals Compute[Decimal] = SumOf[Decimal]
+
+var PartialSumFloats = func(sumGroup, groupSize int) Compute[float64] {
+	return PartialSumOf[float64](sumGroup, groupSize)
+}
+
+var PartialSumDecimals = func(sumGroup, groupSize int) Compute[Decimal] {
+	return PartialSumOf[Decimal](sumGroup, groupSize)
+}
+
+func SumOf[T valueType](elements []RawValue) (T, error) {
+	var result T
+	arithmetic := arithmeticOf[T]()
 	for _, element := range elements {
-		f, ok := element.(float64)
+		e, ok := element.(T)
 		if !ok {
-			return 0, InvalidType(element)
+			return result, InvalidType(element)
+		}
+		var err error
+		result, err = arithmetic.checkedAdd(result, e)
+		if err != nil {
+			return result, err
 		}
-		result += f
 	}
 	return result, nil
 }
 
-var PartialSumFloats = func(sumGroup, groupSize int) Compute[float64] {
-	return func(elements []RawValue) (float64, error) {
-		var result float64
+func PartialSumOf[T valueType](sumGroup, groupSize int) Compute[T] {
+	return func(elements []RawValue) (T, error) {
+		var result T
+		arithmetic := arithmeticOf[T]()
 		for i, element := range elements {
-			e, ok := element.(float64)
+			e, ok := element.(T)
 			if !ok {
-				return 0, InvalidType(element)
+				return result, InvalidType(element)
 			}
 			if i >= groupSize*(sumGroup-1) && i < groupSize*sumGroup {
-				result += e
+				var err error
+				result, err = arithmetic.checkedAdd(result, e)
+				if err != nil {
+					return result, err
+				}
 			}
 		}
 		return result, nil
 	}
 }
 
+type ZeroDivision int
+
+const (
+	// ZeroAsNaN gives NaN for float64 values, and behaves like ZeroAsEmpty for other value types
+	ZeroAsNaN ZeroDivision = iota
+	ZeroAsEmpty
+	ZeroAsZero
+	ZeroAsError
+)
+
+func divide[T valueType](numerator, denominator T, onZero ZeroDivision) (T, error) {
+	a := arithmeticOf[T]()
+	if !a.isZero(denominator) {
+		return a.div(numerator, denominator), nil
+	}
+	var zero T
+	switch onZero {
+	case ZeroAsNaN:
+		if a.nan != nil {
+			return *a.nan, nil
+		}
+		return zero, ErrEmptyValue
+	case ZeroAsEmpty:
+		return zero, ErrEmptyValue
+	case ZeroAsZero:
+		return zero, nil
+	default:
+		return zero, ErrDivisionByZero
+	}
+}
+
+func operands[T valueType](elements []RawValue) (T, T, error) {
+	var a, b T
+	if len(elements) != 2 {
+		return a, b, fmt.Errorf("expected 2 elements, got %d", len(elements))
+	}
+	a, ok := elements[0].(T)
+	if !ok {
+		return a, b, InvalidType(elements[0])
+	}
+	b, ok = elements[1].(T)
+	if !ok {
+		return a, b, InvalidType(elements[1])
+	}
+	return a, b, nil
+}
+
+// Ratio divides the first data reference by the second one, for instance
+// ComputedValues("V4/V2", []DataRef{Ref(4, Sum), Ref(2, Sum)}, Ratio[float64](ZeroAsNaN), Digits(2)).
+// It is not available for integer tables, whose divisions would be truncated.
+func Ratio[T fractionalType](onZero ZeroDivision) Compute[T] {
+	return func(elements []RawValue) (T, error) {
+		numerator, denominator, err := operands[T](elements)
+		if err != nil {
+			return numerator, err
+		}
+		return divide(numerator, denominator, onZero)
+	}
+}
+
+// WeightedAverage expects a Weighted data reference followed by the matching Weights, for instance
+// ComputedValues("Avg", []DataRef{Weighted(4, 2), Weights(4, 2)}, WeightedAverage[float64](ZeroAsEmpty), Digits(2))
+func WeightedAverage[T fractionalType](onZero ZeroDivision) Compute[T] {
+	return Ratio[T](onZero)
+}
+
+// subtract fails instead of wrapping when the difference of unsigned values is negative
+func subtract[T valueType](a, b T) (T, error) {
+	arithmetic := arithmeticOf[T]()
+	if _, unsigned := interface{}(a).(uint64); unsigned && arithmetic.less(a, b) {
+		return a, fmt.Errorf("negative difference of unsigned values %v and %v", a, b)
+	}
+	return arithmetic.sub(a, b), nil
+}
+
+// Difference subtracts the second data reference from the first one
+func Difference[T valueType](elements []RawValue) (T, error) {
+	a, b, err := operands[T](elements)
+	if err != nil {
+		return a, err
+	}
+	return subtract(a, b)
+}
+
+// Growth gives the relative change from the second data reference to the first one, for float64 or Decimal tables
+func Growth[T fractionalType](onZero ZeroDivision) Compute[T] {
+	return func(elements []RawValue) (T, error) {
+		current, previous, err := operands[T](elements)
+		if err != nil {
+			return current, err
+		}
+		change, err := subtract(current, previous)
+		if err != nil {
+			return change, err
+		}
+		return divide(change, previous, onZero)
+	}
+}
+
+var ConcatDistinct = func(sep string) TextAggregate {
+	return func(texts []string) string {
+		seen := make(map[string]bool)
+		var result []string
+		for _, t := range texts {
+			if !seen[t] {
+				seen[t] = true
+				result = append(result, t)
+			}
+		}
+		return strings.Join(result, sep)
+	}
+}
+
+var List = func(sep string) TextAggregate {
+	return func(texts []string) string {
+		return strings.Join(texts, sep)
+	}
+}
+
+// Mode returns the most frequent text, the first recorded one in case of tie
+var Mode TextAggregate = func(texts []string) string {
+	counts := make(map[string]int)
+	for _, t := range texts {
+		counts[t]++
+	}
+	var result string
+	for _, t := range texts {
+		if counts[t] > counts[result] {
+			result = t
+		}
+	}
+	return result
+}
+
+var MinString TextAggregate = func(texts []string) string {
+	var result string
+	for i, t := range texts {
+		if i == 0 || t < result {
+			result = t
+		}
+	}
+	return result
+}
+
+var MaxString TextAggregate = func(texts []string) string {
+	var result string
+	for _, t := range texts {
+		if t > result {
+			result = t
+		}
+	}
+	return result
+}
+
+var FirstString TextAggregate = func(texts []string) string {
+	if len(texts) == 0 {
+		return ""
+	}
+	return texts[0]
+}
+
+var LastString TextAggregate = func(texts []string) string {
+	if len(texts) == 0 {
+		return ""
+	}
+	return texts[len(texts)-1]
+}
+
 var In = func(list []string) Filter {
 	return func(element RawValue) bool {
 		for _, e := range list {
@@ -96,6 +288,133 @@ var In = func(list []string) Filter {
 	}
 }
 
+var Not = func(filter Filter) Filter {
+	return func(element RawValue) bool {
+		return !filter(element)
+	}
+}
+
+var And = func(filters ...Filter) Filter {
+	return func(element RawValue) bool {
+		for _, f := range filters {
+			if !f(element) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+var Or = func(filters ...Filter) Filter {
+	return func(element RawValue) bool {
+		for _, f := range filters {
+			if f(element) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var NotIn = func(list []string) Filter {
+	return Not(In(list))
+}
+
+var Equals = func(value RawValue) Filter {
+	return func(element RawValue) bool {
+		return element == value
+	}
+}
+
+var Contains = func(substr string) Filter {
+	return func(element RawValue) bool {
+		return strings.Contains(toString(element), substr)
+	}
+}
+
+var HasPrefix = func(prefix string) Filter {
+	return func(element RawValue) bool {
+		return strings.HasPrefix(toString(element), prefix)
+	}
+}
+
+var HasSuffix = func(suffix string) Filter {
+	return func(element RawValue) bool {
+		return strings.HasSuffix(toString(element), suffix)
+	}
+}
+
+// Regex panics if pattern does not compile, like regexp.MustCompile
+var Regex = func(pattern string) Filter {
+	re := regexp.MustCompile(pattern)
+	return func(element RawValue) bool {
+		return re.MatchString(toString(element))
+	}
+}
+
+var IsEmpty Filter = func(element RawValue) bool {
+	return element == nil || element == ""
+}
+
+// NotBlank drops empty or nil elements, so that a row or column series has no blank header
+var NotBlank Filter = func(element RawValue) bool {
+	return !IsEmpty(element)
+}
+
+// Between keeps numeric elements in [min,max], non numeric elements are dropped. Strings are parsed without the table
+// locale, guessing their decimal separator, filter a column declared in a Schema to parse them with the locale.
+var Between = func(min, max float64) Filter {
+	return func(element RawValue) bool {
+		f, err := toFloat(element)
+		return err == nil && f >= min && f <= max
+	}
+}
+
+// GreaterThan keeps numeric elements above value, strings being parsed as by Between
+var GreaterThan = func(value float64) Filter {
+	return func(element RawValue) bool {
+		f, err := toFloat(element)
+		return err == nil && f > value
+	}
+}
+
+// LessThan keeps numeric elements below value, strings being parsed as by Between
+var LessThan = func(value float64) Filter {
+	return func(element RawValue) bool {
+		f, err := toFloat(element)
+		return err == nil && f < value
+	}
+}
+
+// DateBetween keeps dates in [from,to], elements are either time.Time or strings parsed with layout
+var DateBetween = func(layout string, from, to time.Time) Filter {
+	return func(element RawValue) bool {
+		d, err := toTime(element, layout)
+		return err == nil && !d.Before(from) && !d.After(to)
+	}
+}
+
+var Before = func(layout string, date time.Time) Filter {
+	return func(element RawValue) bool {
+		d, err := toTime(element, layout)
+		return err == nil && d.Before(date)
+	}
+}
+
+var After = func(layout string, date time.Time) Filter {
+	return func(element RawValue) bool {
+		d, err := toTime(element, layout)
+		return err == nil && d.After(date)
+	}
+}
+
+// On applies a value filter to the record element at given index
+var On = func(index int, filter Filter) RecordFilter {
+	return func(record []interface{}) bool {
+		return filter(record[index])
+	}
+}
+
 func Digits(n int) string {
 	return "%." + strconv.Itoa(n) + "f"
 }
@@ -105,3 +424,5 @@ func InvalidType(element interface{}) error {
 }
 
 var ErrEmptyValue = errors.New("empty value")
+
+var ErrDivisionByZero = errors.New("division by zero")
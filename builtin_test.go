@@ -0,0 +1,177 @@
+package pivot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFilters(t *testing.T) {
+	checks := []struct {
+		name     string
+		filter   Filter
+		element  RawValue
+		expected bool
+	}{
+		{"Not", Not(Equals("A1")), "A1", false},
+		{"And", And(HasPrefix("A"), Contains("1")), "A1", true},
+		{"Or", Or(Equals("A1"), Equals("A2")), "A3", false},
+		{"NotIn", NotIn([]string{"A1", "A2"}), "A3", true},
+		{"HasSuffix", HasSuffix("1"), "B1", true},
+		{"Regex", Regex("^A[0-9]$"), "A12", false},
+		{"IsEmpty", IsEmpty, "", true},
+		{"IsEmpty nil", IsEmpty, nil, true},
+		{"Between", Between(1, 3), "2,5", true},
+		{"Between int", Between(1, 3), 4, false},
+		{"GreaterThan", GreaterThan(1), 1.5, true},
+		{"LessThan invalid", LessThan(1), "x", false},
+		{"DateBetween", DateBetween("2006-01-02", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC)), "2022-06-15", true},
+		{"Before", Before("2006-01-02", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)), "2022-06-15", false},
+		{"After", After("", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, check := range checks {
+		if check.filter(check.element) != check.expected {
+			t.Fatalf("%s(%v)!=%v", check.name, check.element, check.expected)
+		}
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", 4, 1},
+		{"A1", "B2", 2, 3},
+		{"A2", "B1", 3, 3},
+	}
+	table := NewTable(rawData, false).
+		Row(0).
+		Column(1).
+		Values(2, Sum, Digits(0)).
+		FilterRecords(func(record []interface{}) bool {
+			return record[2].(int) > record[3].(int)
+		})
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;Total\nA1;4;4\nTotal;4;4\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+}
+
+func TestTextAggregates(t *testing.T) {
+	texts := []string{"T2", "T1", "T1", "T2", "T3"}
+	checks := []struct {
+		name      string
+		aggregate TextAggregate
+		expected  string
+	}{
+		{"ConcatDistinct", ConcatDistinct(","), "T2,T1,T3"},
+		{"List", List(","), "T2,T1,T1,T2,T3"},
+		{"Mode", Mode, "T2"},
+		{"MinString", MinString, "T1"},
+		{"MaxString", MaxString, "T3"},
+		{"FirstString", FirstString, "T2"},
+		{"LastString", LastString, "T3"},
+	}
+	for _, check := range checks {
+		if check.aggregate(texts) != check.expected {
+			t.Fatalf("%s(%v)=%s!=%s", check.name, texts, check.aggregate(texts), check.expected)
+		}
+	}
+	rawData := [][]interface{}{
+		{"A1", "B1", "T1", 4},
+		{"A1", "B1", "T2", 2},
+		{"A1", "B1", "", 2},
+		{"A1", "B2", "T1", 3},
+	}
+	table := NewTable(rawData, false).
+		Row(0).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		TextValues(2, ConcatDistinct(" ")).
+		Values(3, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\nA1;[ T1 T2, 8 ];[ T1, 3 ];[ T1 T2, 11 ]\nTotal;[ T1 T2, 8 ];[ T1, 3 ];[ T1 T2, 11 ]\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+}
+
+func TestRatios(t *testing.T) {
+	rawData := [][]interface{}{
+		{"P", "S", "Price", "Quantity", "Last"},
+		{"A1", "B1", 10, 1, 5},
+		{"A1", "B1", 20, 3, 10},
+		{"A1", "B2", 30, 0, 0},
+		{"A2", "B2", 40, 1, 0},
+	}
+	table := NewTable(rawData, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		ComputedValues("Avg", []DataRef{Weighted(2, 3), Weights(2, 3)}, WeightedAverage[float64](ZeroAsEmpty), Digits(2)).
+		ComputedValues("Growth", []DataRef{Ref(3, Sum), Ref(4, Sum)}, Growth[float64](ZeroAsNaN), Digits(2)).
+		ComputedValues("Diff", []DataRef{Ref(3, Sum), Ref(4, Sum)}, Difference[float64], Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\n" +
+		"A1;[ 17.50, -0.73, -11 ];[ , NaN, 0 ];[ 17.50, -0.73, -11 ]\n" +
+		"A2;;[ 40.00, NaN, 1 ];[ 40.00, NaN, 1 ]\n" +
+		"Total;[ 17.50, -0.73, -11 ];[ 40.00, NaN, 1 ];[ 22.00, -0.67, -10 ]\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	decimals := NewDecimalTable(rawData, true).
+		Row(0).
+		Column(1).
+		ComputedValues("Ratio", []DataRef{Ref(2, Sum), Ref(3, Sum)}, Ratio[Decimal](ZeroAsError), Digits(2))
+	err = decimals.Generate()
+	if !errors.Is(err, ErrDivisionByZero) {
+		t.Fatalf("expected division by zero error, got %v", err)
+	}
+	_, err = Difference[uint64]([]RawValue{uint64(1), uint64(2)})
+	if err == nil {
+		t.Fatalf("expected error with negative unsigned difference")
+	}
+	value, err := Difference[uint64]([]RawValue{uint64(2), uint64(1)})
+	if err != nil || value != 1 {
+		t.Fatalf("Difference[uint64](2,1)=%d,%v!=1", value, err)
+	}
+}
+
+func TestWeightedAverageEmpty(t *testing.T) {
+	rawData := [][]interface{}{
+		{"P", "Price", "Quantity"},
+		{"A1", 10, 1},
+		{"A1", "", 3},
+		{"A1", 20, ""},
+	}
+	table := NewTable(rawData, true).
+		Row(0).
+		ComputedValues("Avg", []DataRef{Weighted(1, 2), Weights(1, 2)}, WeightedAverage[float64](ZeroAsEmpty), Digits(2)).
+		EmptyValues(SkipEmpty)
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";Total\nA1;10.00\nTotal;10.00\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	if table.Stats().EmptyValues[1] != 1 || table.Stats().EmptyValues[2] != 1 {
+		t.Fatalf("table.Stats().EmptyValues=%v", table.Stats().EmptyValues)
+	}
+	err = NewTable(append(rawData[:2:2], rawData[3]), true).
+		Row(0).
+		ComputedValues("Avg", []DataRef{Weighted(1, 2), Weights(1, 2)}, WeightedAverage[float64](ZeroAsEmpty), Digits(2)).
+		EmptyValues(FailOnEmpty).
+		Generate()
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || recordErr.Column != 2 {
+		t.Fatalf("expected error on weight column, got %v", err)
+	}
+}
@@ -1,32 +1,170 @@
 package pivot
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strings"
 )
 
 type cell[T valueType] interface {
 	fmt.Stringer
-	Set(index int, compute Compute[T], keys []DataRef) error
+	Set(index int, compute Compute[T], refs []int) error
 	Get() []T
-	Record(key DataRef, value T)
+	Value(index int) RawValue
+	Record(ref int, value T)
+	Retract(ref int, value T)
+	Tally(delta int64)
+	Empty() bool
+	RecordText(ref int, value string)
+	SetText(index int, aggregate TextAggregate, ref int)
+	Merge(other cell[T])
 }
 
+// cellKey identifies a cell by the ids of its row and column headers
+type cellKey struct {
+	row    int
+	column int
+}
+
+// arithmetic gives cells the operations they need on values, whatever the value type
+type arithmetic[T valueType] struct {
+	one     T
+	nan     *T
+	fromInt func(int64) T
+	less    func(T, T) bool
+	add     func(T, T) T
+	sub     func(T, T) T
+	mul     func(T, T) T
+	div     func(T, T) T
+	isZero  func(T) bool
+	// check reports values that cannot be trusted, nil when all values can
+	check func(T) error
+	// addOverflows and mulOverflows tell whether add and mul wrap around, nil when they cannot
+	addOverflows func(T, T) bool
+	mulOverflows func(T, T) bool
+}
+
+var ErrIntegerOverflow = errors.New("integer overflow")
+
+func arithmeticOf[T valueType]() arithmetic[T] {
+	var zero T
+	var result interface{}
+	switch interface{}(zero).(type) {
+	case float64:
+		nan := math.NaN()
+		result = arithmetic[float64]{
+			one:     1,
+			nan:     &nan,
+			fromInt: func(i int64) float64 { return float64(i) },
+			less:    func(a, b float64) bool { return a < b },
+			add:     func(a, b float64) float64 { return a + b },
+			sub:     func(a, b float64) float64 { return a - b },
+			mul:     func(a, b float64) float64 { return a * b },
+			div:     func(a, b float64) float64 { return a / b },
+			isZero:  func(a float64) bool { return a == 0 },
+		}
+	case int64:
+		result = arithmetic[int64]{
+			one:     1,
+			fromInt: func(i int64) int64 { return int64(i) },
+			less:    func(a, b int64) bool { return a < b },
+			add:     func(a, b int64) int64 { return a + b },
+			sub:     func(a, b int64) int64 { return a - b },
+			mul:     func(a, b int64) int64 { return a * b },
+			div:     func(a, b int64) int64 { return a / b },
+			isZero:  func(a int64) bool { return a == 0 },
+			addOverflows: func(a, b int64) bool {
+				return (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)
+			},
+			mulOverflows: func(a, b int64) bool {
+				if a == 0 || b == 0 {
+					return false
+				}
+				return (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || a*b/b != a
+			},
+		}
+	case uint64:
+		result = arithmetic[uint64]{
+			one:     1,
+			fromInt: func(i int64) uint64 { return uint64(i) },
+			less:    func(a, b uint64) bool { return a < b },
+			add:     func(a, b uint64) uint64 { return a + b },
+			sub:     func(a, b uint64) uint64 { return a - b },
+			mul:     func(a, b uint64) uint64 { return a * b },
+			div:     func(a, b uint64) uint64 { return a / b },
+			isZero:  func(a uint64) bool { return a == 0 },
+			addOverflows: func(a, b uint64) bool {
+				return a > math.MaxUint64-b
+			},
+			mulOverflows: func(a, b uint64) bool {
+				return b != 0 && a > math.MaxUint64/b
+			},
+		}
+	case Decimal:
+		result = arithmetic[Decimal]{
+			one:     NewDecimal(1, 0),
+			fromInt: func(i int64) Decimal { return NewDecimal(i, 0) },
+			less:    func(a, b Decimal) bool { return a.Cmp(b) < 0 },
+			add:     Decimal.Add,
+			sub:     Decimal.Sub,
+			mul:     Decimal.Mul,
+			div:     func(a, b Decimal) Decimal { return a.Quo(b, divisionScale) },
+			isZero:  Decimal.IsZero,
+			check: func(d Decimal) error {
+				if d.Overflowed() {
+					return ErrDecimalOverflow
+				}
+				return nil
+			},
+		}
+	}
+	return result.(arithmetic[T])
+}
+
+// checkedAdd adds a and b, failing instead of wrapping around
+func (a *arithmetic[T]) checkedAdd(x, y T) (T, error) {
+	if a.addOverflows != nil && a.addOverflows(x, y) {
+		return x, ErrIntegerOverflow
+	}
+	return a.add(x, y), nil
+}
+
+// pivotCell records values by data reference position in layout.dataRefs
 type pivotCell[T valueType] struct {
 	finalValues    []T
-	recordedValues map[DataRef]T
-	formats        []string
+	finalTexts     map[int]string
+	recordedValues []T
+	recordedCounts []int64
+	recordedTexts  [][]string
+	overflowed     []bool
+	emptyValues    []bool
+	records        int64
+	layout         *layout[T]
 }
 
-func newPivotCell(formats []ValueFormat) cell[float64] {
-	valueFormats := make([]string, len(formats))
-	for i := 0; i < len(formats); i++ {
-		valueFormats[i] = string(formats[i])
-	}
-	return &pivotCell[float64]{
-		finalValues:    make([]float64, len(formats)),
-		recordedValues: make(map[DataRef]float64),
-		formats:        valueFormats,
+// layout holds what is shared by all cells of a table
+type layout[T valueType] struct {
+	formats    []string
+	dataRefs   []DataRef
+	display    *display
+	arithmetic arithmetic[T]
+}
+
+// display holds table wide rendering options shared by all cells
+type display struct {
+	locale  *Locale
+	absent  string
+	invalid *string
+}
+
+func newPivotCell[T valueType](layout *layout[T]) cell[T] {
+	return &pivotCell[T]{
+		finalValues:    make([]T, len(layout.formats)),
+		emptyValues:    make([]bool, len(layout.formats)),
+		recordedValues: make([]T, len(layout.dataRefs)),
+		recordedCounts: make([]int64, len(layout.dataRefs)),
+		layout:         layout,
 	}
 }
 
@@ -35,7 +173,7 @@ func (p *pivotCell[T]) String() string {
 	if len(p.finalValues) > 1 {
 		sb.WriteString("[ ")
 		for i := 0; i < len(p.finalValues); i++ {
-			sb.WriteString(fmt.Sprintf(p.formats[i], p.finalValues[i]))
+			sb.WriteString(p.format(i))
 			if i < len(p.finalValues)-1 {
 				sb.WriteString(", ")
 			}
@@ -43,35 +181,171 @@ func (p *pivotCell[T]) String() string {
 		sb.WriteString(" ]")
 		return sb.String()
 	} else {
-		return fmt.Sprintf(p.formats[0], p.finalValues[0])
+		return p.format(0)
 	}
 }
 
-func (p *pivotCell[T]) Set(index int, compute Compute[T], keys []DataRef) error {
+func (p *pivotCell[T]) format(index int) string {
+	display := p.layout.display
+	if p.emptyValues[index] {
+		return display.absent
+	}
+	if text, ok := p.finalTexts[index]; ok {
+		return fmt.Sprintf(p.layout.formats[index], text)
+	}
+	if f, ok := interface{}(p.finalValues[index]).(float64); ok && display.invalid != nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return *display.invalid
+	}
+	return display.locale.format(fmt.Sprintf(p.layout.formats[index], p.finalValues[index]))
+}
+
+func (p *pivotCell[T]) Set(index int, compute Compute[T], refs []int) error {
+	var elements []RawValue
+	for _, ref := range refs {
+		value, ok := p.recorded(ref)
+		if !ok {
+			p.emptyValues[index] = true
+			return nil
+		}
+		if check := p.layout.arithmetic.check; check != nil {
+			if err := check(value); err != nil {
+				return err
+			}
+		}
+		if p.overflowed != nil && p.overflowed[ref] {
+			return ErrIntegerOverflow
+		}
+		elements = append(elements, value)
+	}
 	if compute != nil {
-		var elements []RawValue
 		var err error
-		for _, key := range keys {
-			elements = append(elements, p.recordedValues[key])
-		}
 		p.finalValues[index], err = compute(elements)
-		if err != nil {
+		p.emptyValues[index] = errors.Is(err, ErrEmptyValue)
+		if err != nil && !p.emptyValues[index] {
 			return fmt.Errorf("while computing for %v: %w", elements, err)
 		}
 	} else {
-		p.finalValues[index] = p.recordedValues[keys[0]]
+		p.finalValues[index] = elements[0].(T)
+		p.emptyValues[index] = false
+	}
+	if check := p.layout.arithmetic.check; check != nil && !p.emptyValues[index] {
+		return check(p.finalValues[index])
 	}
 	return nil
 }
 
+// recorded returns the aggregated value for ref, false when no value was recorded for an operation needing one
+func (p *pivotCell[T]) recorded(ref int) (T, bool) {
+	a := &p.layout.arithmetic
+	count := p.recordedCounts[ref]
+	switch p.layout.dataRefs[ref].operation {
+	case Count:
+		return a.fromInt(count), true
+	case Average:
+		if count == 0 {
+			return p.recordedValues[ref], false
+		}
+		return a.div(p.recordedValues[ref], a.fromInt(count)), true
+	default:
+		return p.recordedValues[ref], count > 0
+	}
+}
+
 func (p *pivotCell[T]) Get() []T {
 	return p.finalValues
 }
 
-func (p *pivotCell[T]) Record(key DataRef, value T) {
-	if key.operation == Sum {
-		p.recordedValues[key] += value
-	} else if key.operation == Count {
-		p.recordedValues[key]++
+// Value returns the final value at index, a string for text values and nil for empty ones
+func (p *pivotCell[T]) Value(index int) RawValue {
+	if p.emptyValues[index] {
+		return nil
+	}
+	if text, ok := p.finalTexts[index]; ok {
+		return text
+	}
+	return p.finalValues[index]
+}
+
+func (p *pivotCell[T]) Record(ref int, value T) {
+	p.combine(ref, value, 1)
+}
+
+// Retract removes a value previously recorded into ref, only for Count, Sum and Average
+func (p *pivotCell[T]) Retract(ref int, value T) {
+	a := &p.layout.arithmetic
+	switch p.layout.dataRefs[ref].operation {
+	case Sum, weightedSum, weights, Average:
+		p.recordedValues[ref] = a.sub(p.recordedValues[ref], value)
+	}
+	p.recordedCounts[ref]--
+}
+
+// Tally counts the records aggregated into the cell, whether they had values or not
+func (p *pivotCell[T]) Tally(delta int64) {
+	p.records += delta
+}
+
+func (p *pivotCell[T]) Empty() bool {
+	return p.records == 0
+}
+
+// combine aggregates value, standing for count recorded values, into ref
+func (p *pivotCell[T]) combine(ref int, value T, count int64) {
+	a := &p.layout.arithmetic
+	switch p.layout.dataRefs[ref].operation {
+	case Sum, weightedSum, weights, Average:
+		if a.addOverflows != nil && a.addOverflows(p.recordedValues[ref], value) {
+			if p.overflowed == nil {
+				p.overflowed = make([]bool, len(p.layout.dataRefs))
+			}
+			p.overflowed[ref] = true
+		}
+		p.recordedValues[ref] = a.add(p.recordedValues[ref], value)
+	case Min:
+		if p.recordedCounts[ref] == 0 || a.less(value, p.recordedValues[ref]) {
+			p.recordedValues[ref] = value
+		}
+	case Max:
+		if p.recordedCounts[ref] == 0 || a.less(p.recordedValues[ref], value) {
+			p.recordedValues[ref] = value
+		}
+	}
+	p.recordedCounts[ref] += count
+}
+
+func (p *pivotCell[T]) RecordText(ref int, value string) {
+	if p.recordedTexts == nil {
+		p.recordedTexts = make([][]string, len(p.layout.dataRefs))
+	}
+	p.recordedTexts[ref] = append(p.recordedTexts[ref], value)
+}
+
+func (p *pivotCell[T]) SetText(index int, aggregate TextAggregate, ref int) {
+	if p.finalTexts == nil {
+		p.finalTexts = make(map[int]string)
+	}
+	var texts []string
+	if p.recordedTexts != nil {
+		texts = p.recordedTexts[ref]
+	}
+	p.finalTexts[index] = aggregate(texts)
+}
+
+// Merge combines the recorded state of other into p, final values have to be set again afterwards
+func (p *pivotCell[T]) Merge(other cell[T]) {
+	o := other.(*pivotCell[T])
+	p.records += o.records
+	for ref, count := range o.recordedCounts {
+		if count > 0 {
+			p.combine(ref, o.recordedValues[ref], count)
+		}
+	}
+	for ref, texts := range o.recordedTexts {
+		if len(texts) > 0 {
+			if p.recordedTexts == nil {
+				p.recordedTexts = make([][]string, len(p.layout.dataRefs))
+			}
+			p.recordedTexts[ref] = append(p.recordedTexts[ref], texts...)
+		}
 	}
 }
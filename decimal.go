@@ -0,0 +1,300 @@
+package pivot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxDecimalScale = 18
+	divisionScale   = 10
+	// floatScale bounds the decimals kept from float64 input, beyond which digits are binary noise
+	floatScale = 9
+)
+
+var ErrDecimalOverflow = errors.New("decimal overflow")
+
+// Decimal is a fixed-point number holding units of 10^-scale. Operations whose result does not fit return an
+// overflowed decimal, which stays overflowed through further operations, like NaN.
+type Decimal struct {
+	units    int64
+	scale    uint8
+	overflow bool
+}
+
+var overflowed = Decimal{overflow: true}
+
+func NewDecimal(units int64, scale uint8) Decimal {
+	return Decimal{units: units, scale: scale}
+}
+
+// ParseDecimal accepts an optional sign followed by digits, with '.' or ',' as decimal separator
+func ParseDecimal(s string) (Decimal, error) {
+	return parseLocaleDecimal(s, nil)
+}
+
+func parseLocaleDecimal(s string, locale *Locale) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return Decimal{}, ErrEmptyValue
+	}
+	intPart, fracPart, _ := strings.Cut(normalizeNumber(s, locale), ".")
+	if len(fracPart) > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal format for element %q: too many decimals", s)
+	}
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || strings.ContainsAny(fracPart, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal format for element %q", s)
+	}
+	return Decimal{units: units, scale: uint8(len(fracPart))}, nil
+}
+
+func (d Decimal) Overflowed() bool {
+	return d.overflow
+}
+
+func (d Decimal) Float64() float64 {
+	if d.overflow {
+		return math.NaN()
+	}
+	return float64(d.units) / math.Pow10(int(d.scale))
+}
+
+func (d Decimal) String() string {
+	if d.overflow {
+		return "overflow"
+	}
+	if d.scale == 0 {
+		return strconv.FormatInt(d.units, 10)
+	}
+	s := strconv.FormatInt(d.units, 10)
+	sign := ""
+	if d.units < 0 {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= int(d.scale) {
+		s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+	}
+	return sign + s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+}
+
+func pow10(n uint8) int64 {
+	result := int64(1)
+	for i := uint8(0); i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
+func addInt64(a, b int64) (int64, bool) {
+	c := a + b
+	if (a > 0 && b > 0 && c < 0) || (a < 0 && b < 0 && c >= 0) {
+		return 0, false
+	}
+	return c, true
+}
+
+// Rescale returns d with given scale, rounding half away from zero when decimals are dropped
+func (d Decimal) Rescale(scale uint8) Decimal {
+	if d.overflow || scale > maxDecimalScale {
+		return overflowed
+	}
+	if scale >= d.scale {
+		units, ok := mulInt64(d.units, pow10(scale-d.scale))
+		if !ok {
+			return overflowed
+		}
+		return Decimal{units: units, scale: scale}
+	}
+	factor := pow10(d.scale - scale)
+	units, rest := d.units/factor, d.units%factor
+	if rest*2 >= factor {
+		units++
+	} else if rest*2 <= -factor {
+		units--
+	}
+	return Decimal{units: units, scale: scale}
+}
+
+func align(a, b Decimal) (Decimal, Decimal) {
+	if a.scale < b.scale {
+		return a.Rescale(b.scale), b
+	}
+	return a, b.Rescale(a.scale)
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b := align(d, other)
+	if a.overflow || b.overflow {
+		return overflowed
+	}
+	units, ok := addInt64(a.units, b.units)
+	if !ok {
+		return overflowed
+	}
+	return Decimal{units: units, scale: a.scale}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return d.Add(other.Neg())
+}
+
+func (d Decimal) Neg() Decimal {
+	if d.overflow || d.units == math.MinInt64 {
+		return overflowed
+	}
+	return Decimal{units: -d.units, scale: d.scale}
+}
+
+// Mul returns the exact product when it fits, otherwise the product rounded to fewer decimals, never less than
+// the decimals of d or other
+func (d Decimal) Mul(other Decimal) Decimal {
+	if d.overflow || other.overflow {
+		return overflowed
+	}
+	units, ok := mulInt64(d.units, other.units)
+	if ok && d.scale+other.scale <= maxDecimalScale {
+		return Decimal{units: units, scale: d.scale + other.scale}
+	}
+	minScale := d.scale
+	if other.scale > minScale {
+		minScale = other.scale
+	}
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(other.units))
+	for scale := d.scale + other.scale; scale >= minScale; scale-- {
+		if scale > maxDecimalScale {
+			continue
+		}
+		rounded := roundBig(product, d.scale+other.scale-scale)
+		if rounded.IsInt64() {
+			return Decimal{units: rounded.Int64(), scale: scale}
+		}
+	}
+	return overflowed
+}
+
+// roundBig divides n by 10^digits, rounding half away from zero
+func roundBig(n *big.Int, digits uint8) *big.Int {
+	if digits == 0 {
+		return n
+	}
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	quo, rem := new(big.Int).QuoRem(n, factor, new(big.Int))
+	if new(big.Int).Lsh(rem.Abs(rem), 1).Cmp(factor) >= 0 {
+		if n.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+// Cmp returns -1, 0 or 1 whether d is lower, equal or greater than other
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := align(d, other)
+	if a.overflow || b.overflow {
+		// aligning does not fit, the comparison is done on big integers
+		x := new(big.Int).Mul(big.NewInt(d.units), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(other.scale)), nil))
+		y := new(big.Int).Mul(big.NewInt(other.units), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil))
+		return x.Cmp(y)
+	}
+	if a.units < b.units {
+		return -1
+	} else if a.units > b.units {
+		return 1
+	}
+	return 0
+}
+
+// Quo divides d by other with given scale, rounding half away from zero, other must not be zero
+func (d Decimal) Quo(other Decimal, scale uint8) Decimal {
+	if d.overflow || other.overflow {
+		return overflowed
+	}
+	numerator := big.NewInt(d.units)
+	numerator.Mul(numerator, big.NewInt(10).Exp(big.NewInt(10), big.NewInt(int64(scale)+int64(other.scale)), nil))
+	denominator := big.NewInt(other.units)
+	denominator.Mul(denominator, big.NewInt(10).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil))
+	quo, rem := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Lsh(rem.Abs(rem), 1).Cmp(denominator.Abs(denominator)) >= 0 {
+		if (d.units < 0) != (other.units < 0) {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	if !quo.IsInt64() {
+		return overflowed
+	}
+	return Decimal{units: quo.Int64(), scale: scale}
+}
+
+func (d Decimal) IsZero() bool {
+	return d.units == 0 && !d.overflow
+}
+
+// Format implements fmt.Formatter so that Digits formats round exactly instead of going through float64
+func (d Decimal) Format(f fmt.State, verb rune) {
+	var s string
+	switch verb {
+	case 'f', 'F':
+		if precision, ok := f.Precision(); ok {
+			if precision > maxDecimalScale {
+				precision = maxDecimalScale
+			}
+			s = d.Rescale(uint8(precision)).String()
+		} else {
+			s = d.String()
+		}
+	case 'v', 's':
+		s = d.String()
+	default:
+		_, _ = fmt.Fprintf(f, "%"+string(verb), d.Float64())
+		return
+	}
+	if width, ok := f.Width(); ok && len(s) < width {
+		if f.Flag('-') {
+			s += strings.Repeat(" ", width-len(s))
+		} else {
+			s = strings.Repeat(" ", width-len(s)) + s
+		}
+	}
+	_, _ = fmt.Fprint(f, s)
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := parseLocaleDecimal(string(text), LocaleUS)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) GobEncode() ([]byte, error) {
+	return d.MarshalText()
+}
+
+func (d *Decimal) GobDecode(data []byte) error {
+	return d.UnmarshalText(data)
+}
@@ -0,0 +1,111 @@
+package pivot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	a, err := ParseDecimal("-12,345")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	b := NewDecimal(5, 1)
+	checks := []struct {
+		actual   string
+		expected string
+	}{
+		{a.String(), "-12.345"},
+		{a.Add(b).String(), "-11.845"},
+		{a.Sub(b).String(), "-12.845"},
+		{a.Mul(b).String(), "-6.1725"},
+		{a.Rescale(2).String(), "-12.35"},
+		{NewDecimal(1, 0).Quo(NewDecimal(3, 0), 2).String(), "0.33"},
+		{NewDecimal(-2, 0).Quo(NewDecimal(3, 0), 2).String(), "-0.67"},
+		{a.Quo(b, 3).String(), "-24.690"},
+		{NewDecimal(5, 3).String(), "0.005"},
+		{fmt.Sprintf("%.1f", NewDecimal(25, 2)), "0.3"},
+		{fmt.Sprintf("%6.0f|%v", NewDecimal(-25, 1), b), "    -3|0.5"},
+		{fmt.Sprint(a.Cmp(b), b.Cmp(a), b.Cmp(NewDecimal(50, 2))), "-1 1 0"},
+	}
+	for _, check := range checks {
+		if check.actual != check.expected {
+			t.Fatalf("%s!=%s", check.actual, check.expected)
+		}
+	}
+}
+
+func TestDecimalTable(t *testing.T) {
+	var rawData [][]interface{}
+	for i := 0; i < 1000; i++ {
+		rawData = append(rawData, []interface{}{"A1", "B1", 0.1, "0,01"})
+	}
+	table := NewDecimalTable(rawData, false).
+		Row(0).
+		Column(1).
+		ComputedValues("V", DataRefs([]int{2, 3}, Sum), SumDecimals, Digits(15))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;Total\nA1;110.000000000000000;110.000000000000000\nTotal;110.000000000000000;110.000000000000000\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+}
+
+func TestDecimalOverflow(t *testing.T) {
+	large, err := ParseDecimal("10000000000.00")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	checks := []struct {
+		actual   Decimal
+		expected string
+	}{
+		{large.Add(NewDecimal(1, 10)), "overflow"},
+		{NewDecimal(math.MaxInt64, 0).Add(NewDecimal(1, 0)), "overflow"},
+		{NewDecimal(math.MinInt64, 0).Neg(), "overflow"},
+		{large.Rescale(10), "overflow"},
+		{large.Mul(large), "overflow"},
+		{large.Add(NewDecimal(1, 10)).Sub(large), "overflow"},
+		{NewDecimal(3, 9).Mul(NewDecimal(25, 10)), "0.000000000000000008"},
+		{NewDecimal(3000000000, 9).Mul(NewDecimal(25000000000, 10)), "7.500000000000000000"},
+		{large.Quo(NewDecimal(1, 10), 2), "overflow"},
+	}
+	for _, check := range checks {
+		if check.actual.String() != check.expected {
+			t.Fatalf("%s!=%s", check.actual, check.expected)
+		}
+	}
+	if large.Cmp(NewDecimal(1, 10)) != 1 || NewDecimal(1, 10).Cmp(large) != -1 {
+		t.Fatalf("invalid comparison of %s and 0.0000000001", large)
+	}
+	x, y := 0.1, 0.2
+	rawData := [][]interface{}{
+		{"A1", "B1", x + y},
+		{"A1", "B1", 1000.0},
+	}
+	table := NewDecimalTable(rawData, false).
+		Row(0).
+		Column(1).
+		Values(2, Sum, Digits(2))
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;Total\nA1;1000.30;1000.30\nTotal;1000.30;1000.30\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	rawData = [][]interface{}{
+		{"A1", "B1", "9000000000000000000"},
+		{"A1", "B1", "9000000000000000000"},
+	}
+	err = NewDecimalTable(rawData, false).Row(0).Column(1).Values(2, Sum, Digits(0)).Generate()
+	if !errors.Is(err, ErrDecimalOverflow) {
+		t.Fatalf("expected overflow error, got %v", err)
+	}
+}
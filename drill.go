@@ -0,0 +1,117 @@
+package pivot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DrillDown keeps, on Generate, the indexes of the records aggregated into each cell, see Records.
+// Indexes are not kept by snapshots.
+func (t *Table[T]) DrillDown() *Table[T] {
+	t.drillDown = true
+	return t
+}
+
+// keepRecord records that the input record at index was aggregated into the leaf cell at key
+func (t *Table[T]) keepRecord(key cellKey, index int) {
+	if t.drill != nil {
+		t.drill[key] = append(t.drill[key], index)
+	}
+}
+
+// keepRecords records indexes, shifted by offset, for the leaf cell at key
+func (t *Table[T]) keepRecords(key cellKey, indexes []int, offset int) {
+	if t.drill != nil {
+		for _, index := range indexes {
+			t.drill[key] = append(t.drill[key], index+offset)
+		}
+	}
+}
+
+// reindexRecords moves kept indexes to their new positions in input data, indexes mapped to -1 being dropped
+func (t *Table[T]) reindexRecords(positions []int) {
+	for key, indexes := range t.drill {
+		kept := indexes[:0]
+		for _, index := range indexes {
+			if positions[index] >= 0 {
+				kept = append(kept, positions[index])
+			}
+		}
+		if len(kept) == 0 {
+			delete(t.drill, key)
+		} else {
+			t.drill[key] = kept
+		}
+	}
+}
+
+func leaves(h *headers, depth int) []*headers {
+	if h.depth == depth {
+		return []*headers{h}
+	}
+	var result []*headers
+	for _, n := range h.nodes(true, false) {
+		if n.depth == depth {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// Records returns, in input order, the records aggregated into the cell at given paths, subtotals and totals
+// included, the table being generated with DrillDown
+func (t *Table[T]) Records(rowPath, columnPath HeaderPath) ([][]interface{}, error) {
+	if t.drill == nil {
+		return nil, fmt.Errorf("records not kept, see DrillDown")
+	}
+	row := t.rowHeaders.find(rowPath)
+	if row == nil {
+		return nil, fmt.Errorf("row %q not found", rowPath)
+	}
+	column := t.columnHeaders.find(columnPath)
+	if column == nil {
+		return nil, fmt.Errorf("column %q not found", columnPath)
+	}
+	var indexes []int
+	columns := leaves(column, len(t.columnSeries))
+	for _, r := range leaves(row, len(t.rowSeries)) {
+		for _, c := range columns {
+			indexes = append(indexes, t.drill[cellKey{row: r.id, column: c.id}]...)
+		}
+	}
+	sort.Ints(indexes)
+	records := make([][]interface{}, len(indexes))
+	for i, index := range indexes {
+		records[i] = t.data[index]
+	}
+	return records, nil
+}
+
+// DrillThrough writes the records of the cell at given paths as CSV, preceded by input headers if any
+func (t *Table[T]) DrillThrough(w io.Writer, rowPath, columnPath HeaderPath, comma rune) error {
+	records, err := t.Records(rowPath, columnPath)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if t.dataHeaders {
+		records = append([][]interface{}{t.data[0]}, records...)
+	}
+	for _, record := range records {
+		row := make([]string, len(record))
+		for i, element := range record {
+			if element != nil {
+				row[i] = toString(element)
+			}
+		}
+		err = writer.Write(row)
+		if err != nil {
+			return fmt.Errorf("while writing CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,84 @@
+package pivot
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestDrillDown(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "C", "V"},
+		{"A1", "B1", "C1", 4},
+		{"A1", "B2", "C1", 2},
+		{"A2", "B1", "C2", 3},
+		{"A1", "B1", "C2", 1},
+		{"A2", "B2", "C1", 5},
+	}
+	table := NewTable(rawData, true).
+		Row(0).
+		Row(1).
+		Column(2).
+		Values(3, Sum, Digits(0)).
+		DrillDown()
+	_, err := table.Records(nil, nil)
+	if err == nil {
+		t.Fatalf("expected error with records not kept")
+	}
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	records, err := table.Records(HeaderPath{"A1"}, HeaderPath{})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if fmt.Sprint(records) != "[[A1 B1 C1 4] [A1 B2 C1 2] [A1 B1 C2 1]]" {
+		t.Fatalf("records=%v", records)
+	}
+	records, err = table.Records(HeaderPath{"A1", "B1"}, HeaderPath{"C2"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if fmt.Sprint(records) != "[[A1 B1 C2 1]]" {
+		t.Fatalf("records=%v", records)
+	}
+	_, err = table.Records(HeaderPath{"A3"}, HeaderPath{})
+	if err == nil {
+		t.Fatalf("expected error with unknown row")
+	}
+	err = table.Remove(rawData[2])
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = table.Add([]interface{}{"A1", "B3", "C1", 7})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	var buffer bytes.Buffer
+	err = table.DrillThrough(&buffer, HeaderPath{"A1"}, HeaderPath{"C1"}, ';')
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := "A;B;C;V\nA1;B1;C1;4\nA1;B3;C1;7\n"
+	if buffer.String() != expected {
+		t.Fatalf("buffer.String()=%s!=%s", buffer.String(), expected)
+	}
+	parallel := NewTable(rawData, true).
+		Row(0).
+		Row(1).
+		Column(2).
+		Values(3, Sum, Digits(0)).
+		DrillDown()
+	err = parallel.Generate(WithWorkers(3))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	records, err = parallel.Records(HeaderPath{}, HeaderPath{"C1"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if fmt.Sprint(records) != "[[A1 B1 C1 4] [A1 B2 C1 2] [A2 B2 C1 5]]" {
+		t.Fatalf("records=%v", records)
+	}
+}
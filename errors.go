@@ -0,0 +1,33 @@
+package pivot
+
+import "fmt"
+
+// RecordError reports a record that could not be processed, usable with errors.As
+type RecordError struct {
+	// Row is the index of the record in input data, headers included
+	Row int
+	// Column is the index of the faulty element in the record, -1 when the error is not specific to one element
+	Column int
+	Value  RawValue
+	Record []interface{}
+	Err    error
+}
+
+func newRecordError(row int, column int, record []interface{}, err error) *RecordError {
+	var value RawValue
+	if column >= 0 && column < len(record) {
+		value = record[column]
+	}
+	return &RecordError{Row: row, Column: column, Value: value, Record: record, Err: err}
+}
+
+func (e *RecordError) Error() string {
+	if e.Column < 0 {
+		return fmt.Sprintf("record %d %v: %v", e.Row, e.Record, e.Err)
+	}
+	return fmt.Sprintf("record %d column %d (%v): %v", e.Row, e.Column, e.Value, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
@@ -1,3 +1,3 @@
 module github.com/cvila84/pivot
 
-go 1.19
+go 1.21
@@ -3,41 +3,81 @@ package pivot
 import "strings"
 
 const (
+	// HEADER_SEPARATOR is the default separator between header path segments when rendering
 	HEADER_SEPARATOR string = " | "
+	// BLANK_LABEL is the default label of headers grouping empty or nil values
+	BLANK_LABEL string = "(blank)"
 )
 
+// HeaderPath identifies a header by its labels from the first level, the total being the empty path
+type HeaderPath []string
+
+func (p HeaderPath) Join(separator string) string {
+	return strings.Join(p, separator)
+}
+
+func (p HeaderPath) String() string {
+	return p.Join(HEADER_SEPARATOR)
+}
+
+// Parent returns the path without its last label, the total is its own parent
+func (p HeaderPath) Parent() HeaderPath {
+	if len(p) == 0 {
+		return p
+	}
+	return p[:len(p)-1]
+}
+
+// headerTree is shared by all headers of an axis, it indexes them by id and interns their labels
+type headerTree struct {
+	nodes  []*headers
+	labels map[string]string
+}
+
 type headers struct {
+	id          int
 	parent      *headers
 	label       string
+	depth       int
+	tree        *headerTree
 	elements    map[string]*headers
 	defaultSort Sort
 	actualSort  Sort
 }
 
 func newRootHeaders(defaultSort Sort) *headers {
-	return &headers{
+	root := &headers{
+		id:          0,
 		parent:      nil,
 		label:       "",
+		depth:       0,
+		tree:        &headerTree{labels: make(map[string]string)},
 		elements:    nil,
 		defaultSort: defaultSort,
 		actualSort:  defaultSort,
 	}
+	root.tree.nodes = append(root.tree.nodes, root)
+	return root
 }
 
 func newChild(parent *headers, label string) *headers {
-	var childLabel string
-	if len(parent.label) > 0 {
-		childLabel = parent.label + HEADER_SEPARATOR + label
-	} else {
-		childLabel = label
+	interned, ok := parent.tree.labels[label]
+	if !ok {
+		interned = label
+		parent.tree.labels[label] = label
 	}
-	return &headers{
+	child := &headers{
+		id:          len(parent.tree.nodes),
 		parent:      parent,
-		label:       childLabel,
+		label:       interned,
+		depth:       parent.depth + 1,
+		tree:        parent.tree,
 		elements:    nil,
 		defaultSort: parent.defaultSort,
 		actualSort:  nil,
 	}
+	parent.tree.nodes = append(parent.tree.nodes, child)
+	return child
 }
 
 func (h *headers) sort(sort Sort) *headers {
@@ -57,8 +97,49 @@ func (h *headers) walk(label string) *headers {
 	return re
 }
 
-func (h *headers) labels(recursive bool, self bool) []string {
-	labels := make([]string, 0)
+// merge adds other labels to h recursively, ids maps other ids to h tree ids
+func (h *headers) merge(other *headers, ids []int) {
+	ids[other.id] = h.id
+	if h.actualSort == nil {
+		h.actualSort = other.actualSort
+	}
+	for label, child := range other.elements {
+		h.walk(label).merge(child, ids)
+	}
+}
+
+// detach removes h from its parent elements, its id is not reused
+func (h *headers) detach() {
+	if h.parent != nil {
+		delete(h.parent.elements, h.label)
+	}
+}
+
+// path returns the labels from the root to h, the root path being empty
+func (h *headers) path() HeaderPath {
+	path := make(HeaderPath, h.depth)
+	for n := h; n.parent != nil; n = n.parent {
+		path[n.depth-1] = n.label
+	}
+	return path
+}
+
+// find returns the header at path below h, nil if there is none
+func (h *headers) find(path HeaderPath) *headers {
+	n := h
+	for _, label := range path {
+		child, ok := n.elements[label]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
+// nodes returns sorted children of h, recursively if asked, followed by h itself if asked
+func (h *headers) nodes(recursive bool, self bool) []*headers {
+	nodes := make([]*headers, 0)
 	if h.elements != nil {
 		keys := make([]Header, 0, len(h.elements))
 		for k := range h.elements {
@@ -70,28 +151,23 @@ func (h *headers) labels(recursive bool, self bool) []string {
 			keys = h.defaultSort(keys)
 		}
 		for _, k := range keys {
-			labels = append(labels, h.elements[string(k)].label)
+			nodes = append(nodes, h.elements[string(k)])
 			if recursive {
-				subLabels := h.elements[string(k)].labels(recursive, false)
-				if len(subLabels) > 0 {
-					labels = append(labels, subLabels...)
-				}
+				nodes = append(nodes, h.elements[string(k)].nodes(recursive, false)...)
 			}
 		}
 	}
 	if self {
-		labels = append(labels, h.label)
+		nodes = append(nodes, h)
 	}
-	return labels
+	return nodes
 }
 
-func parentHeaderLabel(label string) string {
-	if label == "" {
-		return ""
-	}
-	idx := strings.LastIndex(label, HEADER_SEPARATOR)
-	if idx <= 0 {
-		return ""
+func (h *headers) paths(recursive bool, self bool) []HeaderPath {
+	nodes := h.nodes(recursive, self)
+	paths := make([]HeaderPath, len(nodes))
+	for i, n := range nodes {
+		paths[i] = n.path()
 	}
-	return label[0:idx]
+	return paths
 }
@@ -13,22 +13,31 @@ func TestHeadersWalk(t *testing.T) {
 	h.walk("A2").walk("A2").walk("A3")
 	h.walk("A2").walk("B2").walk("B3")
 	a1a2 := h.walk("A1").walk("A2")
-	a1a2s := a1a2.label
+	a1a2s := a1a2.path().Join("/")
 	if a1a2s != "A1/A2" {
-		t.Fatalf("a1a2.String()=%s!=A1/A2", a1a2s)
+		t.Fatalf("a1a2.path()=%s!=A1/A2", a1a2s)
+	}
+	if a1a2.path().Parent().Join("/") != "A1" {
+		t.Fatalf("a1a2.path().Parent()=%s!=A1", a1a2.path().Parent().Join("/"))
+	}
+	if h.find(HeaderPath{"A1", "A2"}) != a1a2 {
+		t.Fatalf("h.find(A1/A2)!=a1a2")
+	}
+	if h.find(HeaderPath{"A1", "C2"}) != nil {
+		t.Fatalf("h.find(A1/C2)!=nil")
 	}
 	a1b2 := h.walk("A1").walk("B2").sort(ReverseAlphaSort)
-	a1b2l := a1b2.labels(false, false)
+	a1b2l := a1b2.paths(false, false)
 	if len(a1b2l) != 2 {
 		t.Fatalf("len(a1b2l)=%d!=2", len(a1b2l))
 	}
-	if a1b2l[0] != "A1/B2/B3" {
-		t.Fatalf("a1b2l[0]=%s!=A1/B2/B3", a1b2l[0])
+	if a1b2l[0].Join("/") != "A1/B2/B3" {
+		t.Fatalf("a1b2l[0]=%s!=A1/B2/B3", a1b2l[0].Join("/"))
 	}
-	if a1b2l[1] != "A1/B2/A3" {
-		t.Fatalf("a1b2l[0]=%s!=A1/B2/A3", a1b2l[1])
+	if a1b2l[1].Join("/") != "A1/B2/A3" {
+		t.Fatalf("a1b2l[1]=%s!=A1/B2/A3", a1b2l[1].Join("/"))
 	}
 	a1 := h.walk("A1")
-	a1l := a1.labels(true, true)
+	a1l := a1.paths(true, true)
 	fmt.Printf("%+v\n", a1l)
 }
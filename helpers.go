@@ -1,18 +1,41 @@
 package pivot
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
 func toFloat(element RawValue) (float64, error) {
-	switch element.(type) {
+	return toLocaleFloat(element, nil)
+}
+
+func toLocaleFloat(element RawValue, locale *Locale) (float64, error) {
+	switch e := element.(type) {
 	case int:
-		return float64(element.(int)), nil
+		return float64(e), nil
+	case int64:
+		return float64(e), nil
+	case uint64:
+		return float64(e), nil
 	case float64:
-		return element.(float64), nil
+		return e, nil
+	case Decimal:
+		return e.Float64(), nil
 	case string:
+	case nil:
+		return 0, ErrEmptyValue
 	default:
 		return 0, InvalidType(element)
 	}
@@ -20,14 +43,147 @@ func toFloat(element RawValue) (float64, error) {
 	if len(es) == 0 {
 		return 0, ErrEmptyValue
 	}
-	es = strings.Replace(es, ",", ".", 1)
-	result, err := strconv.ParseFloat(es, 32)
+	result, err := strconv.ParseFloat(normalizeNumber(es, locale), 64)
 	if err != nil {
 		return 0, fmt.Errorf("invalid numeric format for element %q", element)
 	}
 	return result, nil
 }
 
+func converterOf[T valueType]() converter[T] {
+	var zero T
+	var result interface{}
+	switch interface{}(zero).(type) {
+	case float64:
+		result = converter[float64](toLocaleFloat)
+	case int64:
+		result = converter[int64](toInt64)
+	case uint64:
+		result = converter[uint64](toUint64)
+	case Decimal:
+		result = converter[Decimal](toDecimal)
+	}
+	return result.(converter[T])
+}
+
+func toInt64(element RawValue, locale *Locale) (int64, error) {
+	switch e := element.(type) {
+	case int:
+		return int64(e), nil
+	case int64:
+		return e, nil
+	case uint64:
+		if e > math.MaxInt64 {
+			return 0, fmt.Errorf("integer overflow for element %d", e)
+		}
+		return int64(e), nil
+	case float64:
+		if e != math.Trunc(e) || math.Abs(e) >= math.MaxInt64 {
+			return 0, fmt.Errorf("invalid integer value for element %v", e)
+		}
+		return int64(e), nil
+	case string:
+		if len(e) == 0 {
+			return 0, ErrEmptyValue
+		}
+		result, err := strconv.ParseInt(normalizeNumber(e, locale), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer format for element %q", e)
+		}
+		return result, nil
+	case nil:
+		return 0, ErrEmptyValue
+	default:
+		return 0, InvalidType(element)
+	}
+}
+
+func toUint64(element RawValue, locale *Locale) (uint64, error) {
+	switch e := element.(type) {
+	case uint64:
+		return e, nil
+	case string:
+		if len(e) == 0 {
+			return 0, ErrEmptyValue
+		}
+		result, err := strconv.ParseUint(normalizeNumber(e, locale), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid unsigned integer format for element %q", e)
+		}
+		return result, nil
+	default:
+		i, err := toInt64(element, locale)
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("invalid negative value for element %v", element)
+		}
+		return uint64(i), nil
+	}
+}
+
+func toDecimal(element RawValue, locale *Locale) (Decimal, error) {
+	switch e := element.(type) {
+	case int:
+		return NewDecimal(int64(e), 0), nil
+	case int64:
+		return NewDecimal(e, 0), nil
+	case float64:
+		if math.IsNaN(e) || math.IsInf(e, 0) {
+			return Decimal{}, fmt.Errorf("invalid decimal value for element %v", e)
+		}
+		s := strconv.FormatFloat(e, 'f', floatScale, 64)
+		return ParseDecimal(strings.TrimSuffix(strings.TrimRight(s, "0"), "."))
+	case Decimal:
+		return e, nil
+	case string:
+		return parseLocaleDecimal(e, locale)
+	case nil:
+		return Decimal{}, ErrEmptyValue
+	default:
+		return Decimal{}, InvalidType(element)
+	}
+}
+
+func toString(element RawValue) string {
+	s, ok := element.(string)
+	if !ok {
+		return fmt.Sprintf("%v", element)
+	}
+	return s
+}
+
+func toTime(element RawValue, layout string) (time.Time, error) {
+	switch e := element.(type) {
+	case time.Time:
+		return e, nil
+	case string:
+		if len(e) == 0 {
+			return time.Time{}, ErrEmptyValue
+		}
+		result, err := time.Parse(layout, e)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date format for element %q: %w", e, err)
+		}
+		return result, nil
+	default:
+		return time.Time{}, InvalidType(element)
+	}
+}
+
+func pageFileName(label string) string {
+	if len(label) == 0 {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, label)
+}
+
 func computeString(serie series[string], record []interface{}) (string, error) {
 	var value string
 	if serie.compute != nil {
@@ -41,50 +197,84 @@ func computeString(serie series[string], record []interface{}) (string, error) {
 			return "", fmt.Errorf("while computing for %v: %w", elements, err)
 		}
 	} else {
-		var ok bool
-		value, ok = record[serie.dataRefs[0].index].(string)
-		if !ok {
-			value = fmt.Sprintf("%v", record[0])
+		element := record[serie.dataRefs[0].index]
+		if element != nil {
+			value = toString(element)
 		}
 	}
 	return value, nil
 }
 
-func filter(filters map[int]Filter, series []*series[string], records [][]interface{}, headers bool) ([][]interface{}, error) {
-	filteredRecords := make([][]interface{}, 0)
+// filter returns the indexes of kept records, records failing to compute a series are handed to fail and dropped.
+// Records are input data from offset, returned indexes being positions in input data.
+func filter(filters map[int]Filter, recordFilters []RecordFilter, series []*series[string], records [][]interface{}, offset int, headers bool, fail func(*RecordError) error, stats *Stats) ([]int, error) {
+	filteredIndexes := make([]int, 0)
 	for i, record := range records {
-		if i != 0 || !headers {
+		if (i+offset != 0 || !headers) && record != nil {
 			keep := true
 			for j, f := range filters {
 				if !f(record[j]) {
+					stats.FilteredByIndex[j]++
+					keep = false
+				}
+			}
+			for j, f := range recordFilters {
+				if !f(record) {
+					stats.FilteredByRecordFilter[j]++
 					keep = false
 				}
 			}
 			for _, serie := range series {
 				value, err := computeString(*serie, record)
 				if err != nil {
-					return nil, fmt.Errorf("while filtering in serie %q for record %v: %w", serie.name, record, err)
+					err = fail(newRecordError(i+offset, serie.column(), record, fmt.Errorf("while filtering in serie %q: %w", serie.name, err)))
+					if err != nil {
+						return nil, err
+					}
+					keep = false
+					break
 				}
 				if serie.filter != nil && !serie.filter(value) {
+					stats.FilteredBySeries[serie.name]++
 					keep = false
 				}
 			}
 			if keep {
-				filteredRecords = append(filteredRecords, record)
+				filteredIndexes = append(filteredIndexes, i+offset)
 			}
 		}
 	}
-	return filteredRecords, nil
+	return filteredIndexes, nil
 }
 
-func walk(headers *headers, series []*series[string], record []interface{}) (string, error) {
+// find returns the headers walk would reach without creating them, nil if there are none
+func find(headers *headers, series []*series[string], record []interface{}, blank string) (*headers, error) {
+	path := make(HeaderPath, len(series))
+	for i, serie := range series {
+		value, err := computeString(*serie, record)
+		if err != nil {
+			return nil, fmt.Errorf("while finding in serie %q for record %v: %w", serie.name, record, err)
+		}
+		if len(value) == 0 {
+			value = blank
+		}
+		path[i] = value
+	}
+	return headers.find(path), nil
+}
+
+// walk descends headers along series values, empty values being grouped under blank
+func walk(headers *headers, series []*series[string], record []interface{}, blank string) (*headers, error) {
 	h := headers
 	for _, serie := range series {
 		value, err := computeString(*serie, record)
 		if err != nil {
-			return "", fmt.Errorf("while walking in serie %q for record %v: %w", serie.name, record, err)
+			return nil, fmt.Errorf("while walking in serie %q for record %v: %w", serie.name, record, err)
+		}
+		if len(value) == 0 {
+			value = blank
 		}
 		h = h.sort(serie.sort).walk(value)
 	}
-	return h.label, nil
+	return h, nil
 }
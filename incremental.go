@@ -0,0 +1,270 @@
+package pivot
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// checkUpdate verifies that records can be added to or removed from t
+func (t *Table[T]) checkUpdate(records [][]interface{}) error {
+	if t.err != nil {
+		return t.err
+	}
+	if t.layout == nil {
+		return fmt.Errorf("table not generated")
+	}
+	// tables loaded from a snapshot have no input data when data has no headers
+	for _, record := range records {
+		if len(t.data) > 0 && len(record) != len(t.data[0]) {
+			return fmt.Errorf("invalid record size %d, expected %d", len(record), len(t.data[0]))
+		}
+	}
+	return nil
+}
+
+// Add aggregates records into a generated table, only computing again the cells they contribute to. Records are
+// filtered, parsed and reported as during Generate, an error may leave the table partially updated.
+func (t *Table[T]) Add(records ...[]interface{}) error {
+	err := t.checkUpdate(records)
+	if err != nil {
+		return err
+	}
+	fail := t.fail(t.logger)
+	offset := len(t.data)
+	t.appendData(records)
+	t.stats.Records += len(records)
+	start := time.Now()
+	data, err := t.parseSchema(records, offset, fail)
+	if err != nil {
+		return err
+	}
+	indexes, err := filter(t.filters, t.recordFilters, t.headerSeries(), data, offset, t.dataHeaders, fail, t.stats)
+	if err != nil {
+		return err
+	}
+	t.stats.FilterDuration += time.Since(start)
+	t.dirty = make(map[cellKey]bool)
+	defer func() { t.dirty = nil }()
+	generatedRecords, err := t.aggregate(data, offset, indexes, fail, t.logger)
+	if err != nil {
+		return err
+	}
+	start = time.Now()
+	for key := range t.dirty {
+		err = t.finalizeCell(key, t.cells[key])
+		if err != nil {
+			return err
+		}
+	}
+	t.stats.AggregateDuration += time.Since(start)
+	t.stats.Generated += len(generatedRecords)
+	t.updateStats()
+	if t.pageIndex >= 0 {
+		return t.updatePages(generatedRecords, true)
+	}
+	return nil
+}
+
+// appendData copies input data once, so that caller slices are never written
+func (t *Table[T]) appendData(records [][]interface{}) {
+	if !t.ownData {
+		t.data = append(make([][]interface{}, 0, len(t.data)+len(records)), t.data...)
+		t.ownData = true
+	}
+	t.data = append(t.data, records...)
+}
+
+// Remove retracts records previously given to NewTable or Add, they must be equal to input records and are removed
+// from input data. Only tables whose values are counts, sums or averages support it. Headers left without records
+// are removed. Records filtered out or skipped as bad are only removed from input data, their errors and statistics
+// with them.
+func (t *Table[T]) Remove(records ...[]interface{}) error {
+	err := t.checkUpdate(records)
+	if err != nil {
+		return err
+	}
+	for _, k := range t.layout.dataRefs {
+		if k.operation == Min || k.operation == Max || k.operation == text {
+			return fmt.Errorf("cannot remove records from a table with min, max or text values")
+		}
+	}
+	positions, err := t.locate(records)
+	if err != nil {
+		return err
+	}
+	// records are handled as if they were appended to input data, their indexes are not reported anyway
+	offset := len(t.data)
+	ignore := func(*RecordError) error { return nil }
+	data, _ := t.parseSchema(records, offset, ignore)
+	filtered := newStats(len(t.recordFilters))
+	indexes, _ := filter(t.filters, t.recordFilters, t.headerSeries(), data, offset, t.dataHeaders, ignore, filtered)
+	t.stats.unfilter(filtered)
+	t.dirty = make(map[cellKey]bool)
+	defer func() { t.dirty = nil }()
+	var removedRecords [][]interface{}
+	parsed := t.newParsedRecord()
+	for _, i := range indexes {
+		record := data[i-offset]
+		if t.parseRecord(i, record, parsed) != nil {
+			continue
+		}
+		row, err := find(t.rowHeaders, t.rowSeries, record, t.blankLabel)
+		if err != nil || row == nil {
+			continue
+		}
+		column, err := find(t.columnHeaders, t.columnSeries, record, t.blankLabel)
+		if err != nil || column == nil {
+			continue
+		}
+		t.retractCells(row, column, parsed)
+		for j, index := range parsed.empties {
+			if j == 0 || index != parsed.empties[j-1] {
+				t.stats.EmptyValues[index]--
+			}
+		}
+		removedRecords = append(removedRecords, record)
+	}
+	removed := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		removed[position] = true
+	}
+	kept := make([][]interface{}, 0, len(t.data)-len(positions))
+	moved := make([]int, len(t.data))
+	for i, record := range t.data {
+		moved[i] = -1
+		if !removed[i] {
+			moved[i] = len(kept)
+			kept = append(kept, record)
+		}
+	}
+	t.reindexRecords(moved)
+	// errors of removed records are dropped, so that skipped records are not reported once removed
+	recordErrors := t.recordErrors[:0]
+	for _, recordErr := range t.recordErrors {
+		if recordErr.Row < len(moved) && moved[recordErr.Row] >= 0 {
+			recordErr.Row = moved[recordErr.Row]
+			recordErrors = append(recordErrors, recordErr)
+		}
+	}
+	t.recordErrors = recordErrors
+	t.data = kept
+	t.ownData = true
+	t.stats.Records -= len(records)
+	t.stats.Generated -= len(removedRecords)
+	err = t.prune()
+	if err != nil {
+		return err
+	}
+	t.updateStats()
+	if t.pageIndex >= 0 {
+		return t.updatePages(removedRecords, false)
+	}
+	return nil
+}
+
+// locate returns the positions of records in input data, each input record matching at most one record
+func (t *Table[T]) locate(records [][]interface{}) ([]int, error) {
+	used := make(map[int]bool, len(records))
+	positions := make([]int, len(records))
+	start := 0
+	if t.dataHeaders {
+		start = 1
+	}
+	for i, record := range records {
+		positions[i] = -1
+		for j := start; j < len(t.data); j++ {
+			if !used[j] && reflect.DeepEqual(t.data[j], record) {
+				positions[i] = j
+				used[j] = true
+				break
+			}
+		}
+		if positions[i] < 0 {
+			return nil, fmt.Errorf("record %v not found", record)
+		}
+	}
+	return positions, nil
+}
+
+func (t *Table[T]) retractCells(row *headers, column *headers, parsed *parsedRecord[T]) {
+	for c := column; c != nil; c = c.parent {
+		for r := row; r != nil; r = r.parent {
+			key := cellKey{row: r.id, column: c.id}
+			rc, ok := t.cells[key]
+			if !ok {
+				continue
+			}
+			for i := range t.layout.dataRefs {
+				if !parsed.skipped[i] {
+					rc.Retract(i, parsed.values[i])
+				}
+			}
+			rc.Tally(-1)
+			t.dirty[key] = true
+		}
+	}
+}
+
+// prune deletes dirty cells left without records and detaches their headers, then computes the other ones again
+func (t *Table[T]) prune() error {
+	rows := make(map[int]bool)
+	columns := make(map[int]bool)
+	for key := range t.dirty {
+		if t.cells[key].Empty() {
+			delete(t.cells, key)
+			delete(t.dirty, key)
+			rows[key.row] = true
+			columns[key.column] = true
+		}
+	}
+	for id := range rows {
+		if _, ok := t.cells[cellKey{row: id, column: t.columnHeaders.id}]; !ok {
+			t.rowHeaders.tree.nodes[id].detach()
+		}
+	}
+	for id := range columns {
+		if _, ok := t.cells[cellKey{row: t.rowHeaders.id, column: id}]; !ok {
+			t.columnHeaders.tree.nodes[id].detach()
+		}
+	}
+	for key := range t.dirty {
+		err := t.finalizeCell(key, t.cells[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updatePages adds or removes aggregated records to the pages they belong to, creating or deleting pages as needed
+func (t *Table[T]) updatePages(records [][]interface{}, add bool) error {
+	pageRecords := make(map[string][][]interface{})
+	for _, record := range records {
+		label := t.pageLabel(record)
+		pageRecords[label] = append(pageRecords[label], record)
+	}
+	for label, recs := range pageRecords {
+		page, ok := t.pages[label]
+		var err error
+		switch {
+		case !add && !ok:
+			continue
+		case !add:
+			err = page.Remove(recs...)
+			if _, ok := page.cells[cellKey{row: page.rowHeaders.id, column: page.columnHeaders.id}]; err == nil && !ok {
+				delete(t.pages, label)
+			}
+		case ok:
+			err = page.Add(recs...)
+		default:
+			page = t.spawn(recs)
+			err = page.Generate(WithLogger(t.logger))
+			t.pages[label] = page
+		}
+		if err != nil {
+			return fmt.Errorf("while updating page %q: %w", label, err)
+		}
+	}
+	return nil
+}
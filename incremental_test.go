@@ -0,0 +1,140 @@
+package pivot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAddRemove(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "P", "V"},
+		{"A1", "B1", "P1", "4"},
+		{"A1", "B2", "P2", "2"},
+		{"A2", "B1", "P1", ""},
+		{"A2", "B1", "P2", "6"},
+		{"A1", "B1", "P2", "3"},
+		{"A2", "B2", "P1", "1"},
+	}
+	input := fmt.Sprint(rawData)
+	table := NewTable(rawData[:4], true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Page(2).
+		Values(3, Sum, Digits(0)).
+		Values(3, Average, Digits(3)).
+		Values(3, Count, Digits(0)).
+		EmptyValues(SkipEmpty)
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	partial := table.ToCSV()
+	err = table.Add(rawData[4:]...)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\n" +
+		"A1;[ 7, 3.500, 2 ];[ 2, 2.000, 1 ];[ 9, 3.000, 3 ]\n" +
+		"A2;[ 6, 6.000, 1 ];[ 1, 1.000, 1 ];[ 7, 3.500, 2 ]\n" +
+		"Total;[ 13, 4.333, 3 ];[ 3, 1.500, 2 ];[ 16, 3.200, 5 ]\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	expected = ";B1;B2;Total\n" +
+		"A1;[ 4, 4.000, 1 ];;[ 4, 4.000, 1 ]\n" +
+		"A2;[ , , 0 ];[ 1, 1.000, 1 ];[ 1, 1.000, 1 ]\n" +
+		"Total;[ 4, 4.000, 1 ];[ 1, 1.000, 1 ];[ 5, 2.500, 2 ]\n"
+	if table.Pages()["P1"].ToCSV() != expected {
+		t.Fatalf("table.Pages()[P1].ToCSV()=%q!=%q", table.Pages()["P1"].ToCSV(), expected)
+	}
+	if table.Stats().Generated != 6 || table.Stats().Cells != 9 {
+		t.Fatalf("unexpected stats %+v", table.Stats())
+	}
+	err = table.Remove(rawData[4:]...)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if table.ToCSV() != partial {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), partial)
+	}
+	if fmt.Sprint(rawData) != input {
+		t.Fatalf("input data was modified")
+	}
+}
+
+func TestRemovePrunesHeaders(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", 4},
+		{"A2", "B2", 2},
+		{"A1", "B2", 3},
+	}
+	table := NewTable(rawData, false).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0))
+	err := table.Remove(rawData[1])
+	if err == nil {
+		t.Fatalf("expected error removing from a table not generated")
+	}
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = table.Remove([]interface{}{"A2", "B2", 2})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\nA1;4;3;7\nTotal;4;3;7\n"
+	result := table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	err = table.Remove([]interface{}{"A2", "B2", 2})
+	if err == nil {
+		t.Fatalf("expected error removing a missing record")
+	}
+	err = table.Add([]interface{}{"A3", "B3", 1})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";B1;B2;B3;Total\nA1;4;3;;7\nA3;;;1;1\nTotal;4;3;1;8\n"
+	result = table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	err = NewTable(rawData, false).Row(0).Column(1).Values(2, Max, Digits(0)).Remove(rawData[0])
+	if err == nil {
+		t.Fatalf("expected error removing from a table with max values")
+	}
+}
+
+func TestRemoveSkippedRecords(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", "x"},
+		{"A1", "B1", 4},
+		{"A2", "B1", "y"},
+		{"A3", "B1", 2},
+	}
+	table := NewTable(rawData, false).
+		Row(0).
+		Column(1).
+		Filter(0, NotIn([]string{"A3"})).
+		Values(2, Sum, Digits(0)).
+		Lenient(2)
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = table.Remove(rawData[0], rawData[3])
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	recordErrors := table.RecordErrors()
+	if len(recordErrors) != 1 || recordErrors[0].Row != 1 || recordErrors[0].Value != "y" {
+		t.Fatalf("unexpected record errors %v", recordErrors)
+	}
+	stats := table.Stats()
+	if stats.Records != 2 || stats.Generated != 1 || stats.Skipped != 1 || stats.FilteredByIndex[0] != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,129 @@
+package pivot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type NegativeStyle int
+
+const (
+	LeadingMinus NegativeStyle = iota
+	TrailingMinus
+	Parentheses
+)
+
+// Locale describes how numbers are written in input data and rendered in output. Empty separators default to "." for
+// decimals and "," for thousands, or "." for thousands when the decimal separator is ",".
+type Locale struct {
+	DecimalSeparator   string
+	ThousandsSeparator string
+	CurrencySymbol     string
+	CurrencyAfter      bool
+	Negative           NegativeStyle
+}
+
+var LocaleUS = &Locale{DecimalSeparator: ".", ThousandsSeparator: ","}
+
+var LocaleEU = &Locale{DecimalSeparator: ",", ThousandsSeparator: "."}
+
+var LocaleUSD = &Locale{DecimalSeparator: ".", ThousandsSeparator: ",", CurrencySymbol: "$", Negative: Parentheses}
+
+var LocaleEUR = &Locale{DecimalSeparator: ",", ThousandsSeparator: " ", CurrencySymbol: "€", CurrencyAfter: true}
+
+// separators returns the decimal and thousands separators, defaulting empty ones
+func (l *Locale) separators() (string, string) {
+	decimal, thousands := l.DecimalSeparator, l.ThousandsSeparator
+	if len(decimal) == 0 {
+		decimal = "."
+	}
+	if len(thousands) == 0 {
+		thousands = ","
+		if decimal == "," {
+			thousands = "."
+		}
+	}
+	return decimal, thousands
+}
+
+var formattedNumber = regexp.MustCompile(`^(-?)([0-9]+)(\.[0-9]+)?`)
+
+// normalizeNumber turns a localized number into a "-1234.56" form understood by strconv.
+// Without locale, the last of ',' or '.' is the decimal separator unless it appears several times.
+func normalizeNumber(s string, locale *Locale) string {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, s = true, strings.TrimSpace(s[1:len(s)-1])
+	} else if strings.HasSuffix(s, "-") {
+		negative, s = true, strings.TrimSpace(s[:len(s)-1])
+	}
+	if locale != nil {
+		if len(locale.CurrencySymbol) > 0 {
+			s = strings.TrimSpace(strings.Replace(s, locale.CurrencySymbol, "", 1))
+		}
+		decimal, thousands := locale.separators()
+		s = strings.ReplaceAll(s, thousands, "")
+		if decimal != "." {
+			s = strings.Replace(s, decimal, ".", 1)
+		}
+	} else {
+		decimal, thousands := ".", ","
+		if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+			decimal, thousands = ",", "."
+		}
+		if strings.Count(s, decimal) > 1 {
+			thousands = decimal
+		}
+		s = strings.ReplaceAll(s, thousands, "")
+		s = strings.Replace(s, decimal, ".", 1)
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// format localizes the number printed at the beginning of s by a fmt verb
+func (l *Locale) format(s string) string {
+	if l == nil {
+		return s
+	}
+	m := formattedNumber.FindStringSubmatchIndex(s)
+	if m == nil {
+		return s
+	}
+	decimal, thousands := l.separators()
+	digits := s[m[4]:m[5]]
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteString(thousands)
+		}
+		sb.WriteRune(d)
+	}
+	if m[6] >= 0 {
+		sb.WriteString(decimal)
+		sb.WriteString(s[m[6]+1 : m[7]])
+	}
+	number := sb.String()
+	if len(l.CurrencySymbol) > 0 {
+		if l.CurrencyAfter {
+			number = fmt.Sprintf("%s %s", number, l.CurrencySymbol)
+		} else {
+			number = l.CurrencySymbol + number
+		}
+	}
+	if m[3] > m[2] {
+		switch l.Negative {
+		case TrailingMinus:
+			number += "-"
+		case Parentheses:
+			number = "(" + number + ")"
+		default:
+			number = "-" + number
+		}
+	}
+	return number + s[m[1]:]
+}
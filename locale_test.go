@@ -0,0 +1,101 @@
+package pivot
+
+import "testing"
+
+func TestParseNumbers(t *testing.T) {
+	checks := []struct {
+		element  string
+		locale   *Locale
+		expected float64
+	}{
+		{"1,5", nil, 1.5},
+		{"1.234,56", nil, 1234.56},
+		{"1,234.56", nil, 1234.56},
+		{"1,234,567", nil, 1234567},
+		{"(12.5)", nil, -12.5},
+		{"1.234", LocaleEU, 1234},
+		{"1,234", LocaleUS, 1234},
+		{"$1,234.56", LocaleUSD, 1234.56},
+		{"1 234,56 €", LocaleEUR, 1234.56},
+		{"12,5-", LocaleEUR, -12.5},
+		{"$12.5", &Locale{CurrencySymbol: "$"}, 12.5},
+		{"$1,234.5", &Locale{CurrencySymbol: "$"}, 1234.5},
+		{"1.234,5", &Locale{DecimalSeparator: ","}, 1234.5},
+	}
+	for _, check := range checks {
+		f, err := toLocaleFloat(check.element, check.locale)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if f != check.expected {
+			t.Fatalf("toLocaleFloat(%q)=%v!=%v", check.element, f, check.expected)
+		}
+	}
+}
+
+func TestFormatNumbers(t *testing.T) {
+	checks := []struct {
+		formatted string
+		locale    *Locale
+		expected  string
+	}{
+		{"-1234.56", nil, "-1234.56"},
+		{"-1234567.50", LocaleUS, "-1,234,567.50"},
+		{"1234.56", LocaleEU, "1.234,56"},
+		{"-1234.56", LocaleUSD, "($1,234.56)"},
+		{"123", LocaleEUR, "123 €"},
+		{"12.5%", LocaleEU, "12,5%"},
+		{"1234.5", &Locale{CurrencySymbol: "$"}, "$1,234.5"},
+	}
+	for _, check := range checks {
+		s := check.locale.format(check.formatted)
+		if s != check.expected {
+			t.Fatalf("format(%q)=%q!=%q", check.formatted, s, check.expected)
+		}
+	}
+}
+
+func TestTableLocale(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", "1.234,50"},
+		{"A1", "B2", "2.000,25"},
+	}
+	table := NewTable(rawData, false).
+		Locale(LocaleEU).
+		Row(0).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(2))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\nA1;1.234,50;2.000,25;3.234,75\nTotal;1.234,50;2.000,25;3.234,75\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+}
+
+func TestLocaleFilter(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "1,500"},
+		{"A2", "2,500"},
+	}
+	table := NewTable(rawData, false).
+		Schema(Schema{{Type: StringColumn}, {Type: Float64Column}}).
+		Locale(LocaleUS).
+		Filter(1, Between(1000, 2000)).
+		Row(0).
+		Values(1, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";Total\nA1;1,500\nTotal;1,500\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	err = NewTable(rawData, false).Locale(&Locale{ThousandsSeparator: "."}).Row(0).Values(1, Sum, Digits(0)).Generate()
+	if err == nil {
+		t.Fatalf("expected error with same decimal and thousands separators")
+	}
+}
@@ -0,0 +1,55 @@
+package pivot
+
+import "fmt"
+
+// Melt turns the columns at valueIndexes of wide data into (variable, value) pairs, giving one record per input record
+// and value column made of id columns, variable and value. Variables are input headers, or column indexes when data
+// has no headers, in which case no header record is returned either.
+func Melt(data [][]interface{}, dataHeaders bool, idIndexes []int, valueIndexes []int, variableName string, valueName string) ([][]interface{}, error) {
+	if len(data) == 0 || (dataHeaders && len(data) == 1) {
+		return nil, fmt.Errorf("no input data")
+	}
+	if len(valueIndexes) == 0 {
+		return nil, fmt.Errorf("no value columns given")
+	}
+	used := make(map[int]bool)
+	for _, index := range append(append([]int{}, idIndexes...), valueIndexes...) {
+		if index < 0 || index >= len(data[0]) {
+			return nil, fmt.Errorf("invalid index %d for %d input columns", index, len(data[0]))
+		}
+		if used[index] {
+			return nil, fmt.Errorf("invalid index %d, already used", index)
+		}
+		used[index] = true
+	}
+	variables := make([]interface{}, len(valueIndexes))
+	for i, index := range valueIndexes {
+		variables[i] = index
+		if dataHeaders {
+			variables[i] = data[0][index]
+		}
+	}
+	var result [][]interface{}
+	records := data
+	if dataHeaders {
+		header := make([]interface{}, 0, len(idIndexes)+2)
+		for _, index := range idIndexes {
+			header = append(header, data[0][index])
+		}
+		result = append(result, append(header, variableName, valueName))
+		records = data[1:]
+	}
+	for i, record := range records {
+		if len(record) != len(data[0]) {
+			return nil, fmt.Errorf("input data has variable records size at record %d", i)
+		}
+		for j, index := range valueIndexes {
+			melted := make([]interface{}, 0, len(idIndexes)+2)
+			for _, id := range idIndexes {
+				melted = append(melted, record[id])
+			}
+			result = append(result, append(melted, variables[j], record[index]))
+		}
+	}
+	return result, nil
+}
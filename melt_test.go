@@ -0,0 +1,48 @@
+package pivot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMelt(t *testing.T) {
+	rawData := [][]interface{}{
+		{"Region", "Product", "Jan", "Feb", "Mar"},
+		{"R1", "P1", 4, 2, ""},
+		{"R2", "P1", 3, 1, 5},
+	}
+	melted, err := Melt(rawData, true, []int{0}, []int{2, 3, 4}, "Month", "Sales")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := "[[Region Month Sales] [R1 Jan 4] [R1 Feb 2] [R1 Mar ] [R2 Jan 3] [R2 Feb 1] [R2 Mar 5]]"
+	if fmt.Sprint(melted) != expected {
+		t.Fatalf("melted=%v!=%s", melted, expected)
+	}
+	table := NewTable(melted, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, MonthSort).
+		Values(2, Sum, Digits(0)).
+		EmptyValues(SkipEmpty)
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";Jan;Feb;Mar;Total\nR1;4;2;;6\nR2;3;1;5;9\nTotal;7;3;5;15\n"
+	result := table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	melted, err = Melt(rawData[1:], false, []int{0, 1}, []int{3}, "Month", "Sales")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = "[[R1 P1 3 2] [R2 P1 3 1]]"
+	if fmt.Sprint(melted) != expected {
+		t.Fatalf("melted=%v!=%s", melted, expected)
+	}
+	_, err = Melt(rawData, true, []int{0}, []int{0, 2}, "Month", "Sales")
+	if err == nil {
+		t.Fatalf("expected error with index used twice")
+	}
+}
@@ -0,0 +1,138 @@
+package pivot
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// compatible tells why other cannot be merged into t, nil if it can
+func (t *Table[T]) compatible(other *Table[T]) error {
+	if t == other {
+		return fmt.Errorf("cannot merge a table with itself")
+	}
+	if t.layout == nil || other.layout == nil {
+		return fmt.Errorf("table not generated")
+	}
+	if len(t.rowSeries) != len(other.rowSeries) || len(t.columnSeries) != len(other.columnSeries) {
+		return fmt.Errorf("incompatible layouts, %d/%d rows and %d/%d columns", len(t.rowSeries), len(other.rowSeries), len(t.columnSeries), len(other.columnSeries))
+	}
+	for i, serie := range t.headerSeries() {
+		o := other.headerSeries()[i]
+		if serie.name != o.name || !reflect.DeepEqual(serie.dataRefs, o.dataRefs) || !sameFunction(serie.filter, o.filter, serie.names.filter, o.names.filter) ||
+			!sameFunction(serie.compute, o.compute, serie.names.compute, o.names.compute) || !sameFunction(serie.sort, o.sort, serie.names.sort, o.names.sort) {
+			return fmt.Errorf("incompatible layouts, serie %q differs from %q", serie.name, o.name)
+		}
+	}
+	if !reflect.DeepEqual(t.layout.dataRefs, other.layout.dataRefs) || !reflect.DeepEqual(t.layout.formats, other.layout.formats) {
+		return fmt.Errorf("incompatible layouts, values differ")
+	}
+	for i, serie := range t.valueSeries {
+		o := other.valueSeries[i]
+		if serie.name != o.name || !sameFunction(serie.compute, o.compute, serie.names.compute, o.names.compute) ||
+			!sameFunction(serie.aggregate, o.aggregate, serie.names.aggregate, o.names.aggregate) {
+			return fmt.Errorf("incompatible layouts, value %q differs from %q", serie.name, o.name)
+		}
+	}
+	if len(t.filters) != len(other.filters) || len(t.recordFilters) != len(other.recordFilters) {
+		return fmt.Errorf("incompatible layouts, filters differ")
+	}
+	for index, filter := range t.filters {
+		if !sameFunction(filter, other.filters[index], t.filterNames[index], other.filterNames[index]) {
+			return fmt.Errorf("incompatible layouts, filters on index %d differ", index)
+		}
+	}
+	for i, filter := range t.recordFilters {
+		if !sameFunction(filter, other.recordFilters[i], t.recordFilterNames[i], other.recordFilterNames[i]) {
+			return fmt.Errorf("incompatible layouts, record filters differ")
+		}
+	}
+	if !reflect.DeepEqual(t.schema, other.schema) || !reflect.DeepEqual(t.display.locale, other.display.locale) {
+		return fmt.Errorf("incompatible layouts, input parsing differs")
+	}
+	if t.emptyPolicy != other.emptyPolicy || t.blankLabel != other.blankLabel {
+		return fmt.Errorf("incompatible layouts, empty values handling differs")
+	}
+	if (t.drill == nil) != (other.drill == nil) {
+		return fmt.Errorf("incompatible layouts, records kept by only one table")
+	}
+	if t.pageIndex != other.pageIndex {
+		return fmt.Errorf("incompatible layouts, pages differ")
+	}
+	return nil
+}
+
+// sameFunction tells whether a and b, named nameA and nameB by the table registry, are the same function. Functions
+// given by value are never the same, as closures of a builtin like Ratio may differ by their arguments.
+func sameFunction(a, b interface{}, nameA, nameB string) bool {
+	if reflect.ValueOf(a).IsNil() || reflect.ValueOf(b).IsNil() {
+		return reflect.ValueOf(a).IsNil() == reflect.ValueOf(b).IsNil()
+	}
+	return len(nameA) > 0 && nameA == nameB
+}
+
+// Merge combines the headers and cells of other, generated with the same rows, columns and values, into t. Its
+// input records are appended to t data, so that t looks like generated from both inputs.
+func (t *Table[T]) Merge(other *Table[T]) error {
+	if t.err != nil {
+		return t.err
+	}
+	err := t.compatible(other)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	rowIds := make([]int, len(other.rowHeaders.tree.nodes))
+	t.rowHeaders.merge(other.rowHeaders, rowIds)
+	columnIds := make([]int, len(other.columnHeaders.tree.nodes))
+	t.columnHeaders.merge(other.columnHeaders, columnIds)
+	for key, c := range other.cells {
+		key = cellKey{row: rowIds[key.row], column: columnIds[key.column]}
+		rc, ok := t.cells[key]
+		if !ok {
+			rc = t.newCell(t.layout)
+			t.cells[key] = rc
+		}
+		rc.Merge(c)
+		err = t.finalizeCell(key, rc)
+		if err != nil {
+			return err
+		}
+	}
+	// other records are appended to t data, after its headers are dropped
+	offset := len(t.data)
+	if other.dataHeaders {
+		offset--
+	}
+	for key, indexes := range other.drill {
+		t.keepRecords(cellKey{row: rowIds[key.row], column: columnIds[key.column]}, indexes, offset)
+	}
+	t.stats.AggregateDuration += time.Since(start)
+	records := other.data
+	if other.dataHeaders {
+		records = records[1:]
+	}
+	t.appendData(records)
+	t.stats.Records += other.stats.Records
+	t.stats.Generated += other.stats.Generated
+	for index, count := range other.stats.EmptyValues {
+		t.stats.EmptyValues[index] += count
+	}
+	t.updateStats()
+	for label, otherPage := range other.pages {
+		page, ok := t.pages[label]
+		if !ok {
+			page = t.spawn(nil)
+			err = page.Generate(WithLogger(t.logger))
+			if err != nil {
+				return fmt.Errorf("while merging page %q: %w", label, err)
+			}
+			t.pages[label] = page
+		}
+		err = page.Merge(otherPage)
+		if err != nil {
+			return fmt.Errorf("while merging page %q: %w", label, err)
+		}
+	}
+	return nil
+}
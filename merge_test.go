@@ -0,0 +1,113 @@
+package pivot
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	header := []interface{}{"A", "B", "P", "V", "T"}
+	firstData := [][]interface{}{
+		header,
+		{"A1", "B1", "P1", "4", "T1"},
+		{"A1", "B2", "P2", "2", "T2"},
+		{"A2", "B1", "P1", "6", "T1"},
+	}
+	secondData := [][]interface{}{
+		header,
+		{"A1", "B1", "P2", "3", "T3"},
+		{"A2", "B2", "P1", "1", "T1"},
+		{"A2", "B1", "P2", "5", "T2"},
+	}
+	registry := NewRegistry().Register("concat", ConcatDistinct(","))
+	var tables []*Table[float64]
+	for _, data := range [][][]interface{}{firstData, secondData} {
+		table := NewTable(data, true).
+			Functions(registry).
+			NamedRow([]int{0}, "", "", "AlphaSort").
+			NamedColumn([]int{1}, "", "", "AlphaSort").
+			Page(2).
+			Values(3, Sum, Digits(0)).
+			Values(3, Average, Digits(1)).
+			Values(3, Max, Digits(0)).
+			NamedTextValues(4, "concat")
+		err := table.Generate()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		tables = append(tables, table)
+	}
+	err := tables[0].Merge(tables[1])
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\n" +
+		"A1;[ 7, 3.5, 4, T1,T3 ];[ 2, 2.0, 2, T2 ];[ 9, 3.0, 4, T1,T2,T3 ]\n" +
+		"A2;[ 11, 5.5, 6, T1,T2 ];[ 1, 1.0, 1, T1 ];[ 12, 4.0, 6, T1,T2 ]\n" +
+		"Total;[ 18, 4.5, 6, T1,T3,T2 ];[ 3, 1.5, 2, T2,T1 ];[ 21, 3.5, 6, T1,T2,T3 ]\n"
+	if tables[0].ToCSV() != expected {
+		t.Fatalf("tables[0].ToCSV()=%q!=%q", tables[0].ToCSV(), expected)
+	}
+	expected = ";B1;B2;Total\n" +
+		"A1;[ 3, 3.0, 3, T3 ];[ 2, 2.0, 2, T2 ];[ 5, 2.5, 3, T2,T3 ]\n" +
+		"A2;[ 5, 5.0, 5, T2 ];;[ 5, 5.0, 5, T2 ]\n" +
+		"Total;[ 8, 4.0, 5, T3,T2 ];[ 2, 2.0, 2, T2 ];[ 10, 3.3, 5, T2,T3 ]\n"
+	if tables[0].Pages()["P2"].ToCSV() != expected {
+		t.Fatalf("tables[0].Pages()[P2].ToCSV()=%q!=%q", tables[0].Pages()["P2"].ToCSV(), expected)
+	}
+	if tables[0].Stats().Records != 6 {
+		t.Fatalf("tables[0].Stats().Records=%d!=6", tables[0].Stats().Records)
+	}
+}
+
+func TestMergeIncompatible(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "P", "V"},
+		{"A1", "B1", "P1", "4"},
+		{"A2", "B2", "P2", "0"},
+	}
+	ratios := NewRegistry().Register("nan", Ratio[float64](ZeroAsNaN)).Register("empty", Ratio[float64](ZeroAsEmpty))
+	refs := []DataRef{Ref(3, Sum), Ref(3, Count)}
+	for _, pair := range [][2]*Table[float64]{
+		{NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)),
+			NewTable(rawData, true).Row(1).Column(0).Values(3, Count, Digits(0))},
+		{NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)),
+			NewTable(rawData, true).Row(0).Column(1).Values(3, Sum, Digits(0))},
+		{NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)),
+			NewTable(rawData, true).ComputedRow([]int{0}, nil, nil, AlphaSort).Column(1).Values(3, Count, Digits(0))},
+		{NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)),
+			NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)).EmptyValues(SkipEmpty)},
+		{NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)),
+			NewTable(rawData, true).Row(0).Column(1).Values(3, Count, Digits(0)).Filter(2, Equals("P1"))},
+		{NewTable(rawData, true).Row(0).ComputedValues("Ratio", refs, Ratio[float64](ZeroAsNaN), Digits(2)),
+			NewTable(rawData, true).Row(0).ComputedValues("Ratio", refs, Ratio[float64](ZeroAsNaN), Digits(2))},
+		{NewTable(rawData, true).Functions(ratios).Row(0).NamedValues("Ratio", refs, "nan", Digits(2)),
+			NewTable(rawData, true).Functions(ratios).Row(0).NamedValues("Ratio", refs, "empty", Digits(2))},
+	} {
+		for _, table := range pair {
+			err := table.Generate()
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+		err := pair[0].Merge(pair[1])
+		if err == nil {
+			t.Fatalf("expected error merging incompatible layouts")
+		}
+	}
+	first := NewTable(rawData[:2], true).Functions(ratios).NamedRow([]int{0}, "", "", "AlphaSort").NamedValues("Ratio", refs, "nan", Digits(2))
+	second := NewTable([][]interface{}{rawData[0], rawData[2]}, true).Functions(ratios).NamedRow([]int{0}, "", "", "AlphaSort").NamedValues("Ratio", refs, "nan", Digits(2))
+	for _, table := range []*Table[float64]{first, second} {
+		err := table.Generate()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+	}
+	err := first.Merge(second)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";Total\nA1;4.00\nA2;0.00\nTotal;2.00\n"
+	if first.ToCSV() != expected {
+		t.Fatalf("first.ToCSV()=%q!=%q", first.ToCSV(), expected)
+	}
+}
@@ -0,0 +1,115 @@
+package pivot
+
+import (
+	"sync"
+	"time"
+)
+
+type workerResult[T valueType] struct {
+	table            *Table[T]
+	generatedRecords [][]interface{}
+	recordErrors     []*RecordError
+	err              error
+}
+
+// worker creates an empty table sharing t definitions, used to aggregate a share of records
+func (t *Table[T]) worker() *Table[T] {
+	w := &Table[T]{
+		cells:         make(map[cellKey]cell[T]),
+		rowHeaders:    newRootHeaders(t.rowHeaders.defaultSort),
+		columnHeaders: newRootHeaders(t.columnHeaders.defaultSort),
+		rowSeries:     t.rowSeries,
+		columnSeries:  t.columnSeries,
+		valueSeries:   t.valueSeries,
+		layout:        t.layout,
+		newCell:       t.newCell,
+		cellValue:     t.cellValue,
+		arithmetic:    t.arithmetic,
+		display:       t.display,
+		emptyPolicy:   t.emptyPolicy,
+		blankLabel:    t.blankLabel,
+		stats:         newStats(0),
+	}
+	if t.drill != nil {
+		w.drill = make(map[cellKey][]int)
+	}
+	return w
+}
+
+// aggregateParallel splits indexes in contiguous shares aggregated by workers, then merges workers headers and
+// cells in shares order, so that the result does not depend on scheduling. Bad records are handed to fail in
+// records order once all workers are done.
+func (t *Table[T]) aggregateParallel(data [][]interface{}, indexes []int, fail func(*RecordError) error, config *generateConfig) ([][]interface{}, error) {
+	workers := config.workers
+	if workers > len(indexes) {
+		workers = len(indexes)
+	}
+	results := make([]workerResult[T], workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		share := indexes[w*len(indexes)/workers : (w+1)*len(indexes)/workers]
+		result := &results[w]
+		result.table = t.worker()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collect := func(err *RecordError) error {
+				result.recordErrors = append(result.recordErrors, err)
+				return nil
+			}
+			result.generatedRecords, result.err = result.table.aggregate(data, 0, share, collect, config.logger)
+		}()
+	}
+	wg.Wait()
+	var generatedRecords [][]interface{}
+	for _, result := range results {
+		for _, recordErr := range result.recordErrors {
+			err := fail(recordErr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if result.err != nil {
+			return nil, result.err
+		}
+	}
+	start := time.Now()
+	for _, result := range results {
+		rowIds := make([]int, len(result.table.rowHeaders.tree.nodes))
+		t.rowHeaders.merge(result.table.rowHeaders, rowIds)
+		columnIds := make([]int, len(result.table.columnHeaders.tree.nodes))
+		t.columnHeaders.merge(result.table.columnHeaders, columnIds)
+		for key, c := range result.table.cells {
+			t.mergeCell(cellKey{row: rowIds[key.row], column: columnIds[key.column]}, c)
+		}
+		for key, indexes := range result.table.drill {
+			t.keepRecords(cellKey{row: rowIds[key.row], column: columnIds[key.column]}, indexes, 0)
+		}
+		for index, count := range result.table.stats.EmptyValues {
+			t.stats.EmptyValues[index] += count
+		}
+		if result.table.stats.WalkDuration > t.stats.WalkDuration {
+			t.stats.WalkDuration = result.table.stats.WalkDuration
+		}
+		if result.table.stats.AggregateDuration > t.stats.AggregateDuration {
+			t.stats.AggregateDuration = result.table.stats.AggregateDuration
+		}
+		generatedRecords = append(generatedRecords, result.generatedRecords...)
+	}
+	err := t.finalize()
+	if err != nil {
+		return nil, err
+	}
+	t.stats.AggregateDuration += time.Since(start)
+	return generatedRecords, nil
+}
+
+// mergeCell combines the recorded state of c into the cell of t at given key
+func (t *Table[T]) mergeCell(key cellKey, c cell[T]) {
+	rc, ok := t.cells[key]
+	if !ok {
+		t.cells[key] = c
+		return
+	}
+	rc.Merge(c)
+}
@@ -0,0 +1,59 @@
+package pivot
+
+import (
+	"testing"
+)
+
+func TestParallelGenerate(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "C", "V", "T"},
+		{"A1", "B1", "C1", "4", "T1"},
+		{"A1", "B2", "C2", "2", "T2"},
+		{"A2", "B1", "C1", "", "T3"},
+		{"A2", "B1", "C2", "6", "T1"},
+		{"A1", "B1", "C2", "x", "T2"},
+		{"A1", "B1", "C1", "3", "T3"},
+		{"A2", "B2", "C1", "1", "T1"},
+		{"A1", "B2", "C1", "5", "T2"},
+		{"A2", "B1", "C2", "x", "T3"},
+	}
+	newTable := func() *Table[float64] {
+		return NewTable(rawData, true).
+			ComputedRow([]int{0}, nil, nil, AlphaSort).
+			ComputedRow([]int{1}, nil, nil, AlphaSort).
+			ComputedColumn([]int{2}, nil, nil, AlphaSort).
+			Values(3, Sum, Digits(0)).
+			Values(3, Average, Digits(1)).
+			Values(3, Min, Digits(0)).
+			Values(3, Count, Digits(0)).
+			TextValues(4, List("")).
+			EmptyValues(SkipEmpty).
+			Lenient(10)
+	}
+	expected := ";C1;C2;Total\n" +
+		"A1;[ 12, 4.0, 3, 3, T1T3T2 ];[ 2, 2.0, 2, 1, T2 ];[ 14, 3.5, 2, 4, T1T2T3T2 ]\n" +
+		"A1 | B1;[ 7, 3.5, 3, 2, T1T3 ];;[ 7, 3.5, 3, 2, T1T3 ]\n" +
+		"A1 | B2;[ 5, 5.0, 5, 1, T2 ];[ 2, 2.0, 2, 1, T2 ];[ 7, 3.5, 2, 2, T2T2 ]\n" +
+		"A2;[ 1, 1.0, 1, 1, T3T1 ];[ 6, 6.0, 6, 1, T1 ];[ 7, 3.5, 1, 2, T3T1T1 ]\n" +
+		"A2 | B1;[ , , , 0, T3 ];[ 6, 6.0, 6, 1, T1 ];[ 6, 6.0, 6, 1, T3T1 ]\n" +
+		"A2 | B2;[ 1, 1.0, 1, 1, T1 ];;[ 1, 1.0, 1, 1, T1 ]\n" +
+		"Total;[ 13, 3.2, 1, 4, T1T3T3T1T2 ];[ 8, 4.0, 2, 2, T2T1 ];[ 21, 3.5, 1, 6, T1T2T3T1T3T1T2 ]\n"
+	for _, workers := range []int{1, 2, 3, 8} {
+		table := newTable()
+		err := table.Generate(WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if table.ToCSV() != expected {
+			t.Fatalf("table.ToCSV()=%q!=%q with %d workers", table.ToCSV(), expected, workers)
+		}
+		recordErrors := table.RecordErrors()
+		if len(recordErrors) != 2 || recordErrors[0].Row != 5 || recordErrors[1].Row != 9 {
+			t.Fatalf("unexpected record errors %v with %d workers", recordErrors, workers)
+		}
+	}
+	err := newTable().Lenient(1).Generate(WithWorkers(4))
+	if err == nil {
+		t.Fatalf("expected error with more bad records than allowed")
+	}
+}
@@ -0,0 +1,137 @@
+package pivot
+
+type RenderOption func(*renderConfig)
+
+type renderConfig struct {
+	separator string
+	rows      axisRender
+	columns   axisRender
+}
+
+// axisRender tells which headers of an axis are expanded, that is have their children rendered
+type axisRender struct {
+	depth     int
+	collapsed []HeaderPath
+	expanded  []HeaderPath
+}
+
+// WithSeparator sets the separator between header path labels, HEADER_SEPARATOR by default
+func WithSeparator(separator string) RenderOption {
+	return func(c *renderConfig) {
+		c.separator = separator
+	}
+}
+
+// RowDepth renders row headers down to depth only, their subtotals standing for hidden levels
+func RowDepth(depth int) RenderOption {
+	return func(c *renderConfig) {
+		c.rows.depth = depth
+	}
+}
+
+// ColumnDepth renders column headers down to depth only, their subtotals standing for hidden levels
+func ColumnDepth(depth int) RenderOption {
+	return func(c *renderConfig) {
+		c.columns.depth = depth
+	}
+}
+
+// CollapseRows hides the descendants of rows at given paths, whatever the depth
+func CollapseRows(paths ...HeaderPath) RenderOption {
+	return func(c *renderConfig) {
+		c.rows.collapsed = append(c.rows.collapsed, paths...)
+	}
+}
+
+// ExpandRows renders the children of rows at given paths, whatever the depth
+func ExpandRows(paths ...HeaderPath) RenderOption {
+	return func(c *renderConfig) {
+		c.rows.expanded = append(c.rows.expanded, paths...)
+	}
+}
+
+// CollapseColumns hides the descendants of columns at given paths, whatever the depth
+func CollapseColumns(paths ...HeaderPath) RenderOption {
+	return func(c *renderConfig) {
+		c.columns.collapsed = append(c.columns.collapsed, paths...)
+	}
+}
+
+// ExpandColumns renders the children of columns at given paths, whatever the depth
+func ExpandColumns(paths ...HeaderPath) RenderOption {
+	return func(c *renderConfig) {
+		c.columns.expanded = append(c.columns.expanded, paths...)
+	}
+}
+
+func newRenderConfig(options []RenderOption) *renderConfig {
+	config := &renderConfig{separator: HEADER_SEPARATOR}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// visible returns the headers of root to render in order, those having an ancestor not expanded being hidden
+func (a *axisRender) visible(root *headers) []*headers {
+	states := make(map[*headers]bool)
+	for _, path := range a.collapsed {
+		if n := root.find(path); n != nil {
+			states[n] = false
+		}
+	}
+	for _, path := range a.expanded {
+		if n := root.find(path); n != nil {
+			states[n] = true
+		}
+	}
+	expanded := func(n *headers) bool {
+		if state, ok := states[n]; ok {
+			return state
+		}
+		return a.depth <= 0 || n.depth < a.depth
+	}
+	var result []*headers
+	for _, n := range root.nodes(true, true) {
+		show := true
+		for p := n.parent; p != nil && show; p = p.parent {
+			show = expanded(p)
+		}
+		if show {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// ToRecords returns one record per leaf cell and value series, made of row labels, column labels, series name and
+// value, preceded by a header record. Values are T, strings for text values or nil when empty, so that records can
+// be given back to NewTable.
+func (t *Table[T]) ToRecords() [][]interface{} {
+	header := make([]interface{}, 0, len(t.rowSeries)+len(t.columnSeries)+2)
+	for _, serie := range t.headerSeries() {
+		header = append(header, serie.name)
+	}
+	header = append(header, "Series", "Value")
+	records := [][]interface{}{header}
+	columns := leaves(t.columnHeaders, len(t.columnSeries))
+	for _, row := range leaves(t.rowHeaders, len(t.rowSeries)) {
+		for _, column := range columns {
+			c, ok := t.cells[cellKey{row: row.id, column: column.id}]
+			if !ok {
+				continue
+			}
+			for i, serie := range t.valueSeries {
+				record := make([]interface{}, 0, len(header))
+				for _, label := range row.path() {
+					record = append(record, label)
+				}
+				for _, label := range column.path() {
+					record = append(record, label)
+				}
+				records = append(records, append(record, serie.name, c.Value(i)))
+			}
+		}
+	}
+	return records
+}
@@ -0,0 +1,110 @@
+package pivot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCollapse(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", "C1", "D1", 4},
+		{"A1", "B2", "C1", "D2", 2},
+		{"A2", "B1", "C2", "D1", 3},
+		{"A2", "B2", "C2", "D1", 1},
+	}
+	table := NewTable(rawData, false).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedRow([]int{1}, nil, nil, AlphaSort).
+		ComputedColumn([]int{2}, nil, nil, AlphaSort).
+		ComputedColumn([]int{3}, nil, nil, AlphaSort).
+		Values(4, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";C1;C2;Total\nA1;6;;6\nA2;;4;4\nTotal;6;4;10\n"
+	result := table.ToCSV(RowDepth(1), ColumnDepth(1))
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	expected = ";C1;C2;Total\nA1;6;;6\nA1 | B1;4;;4\nA1 | B2;2;;2\nA2;;4;4\nTotal;6;4;10\n"
+	result = table.ToCSV(RowDepth(1), ExpandRows(HeaderPath{"A1"}), ColumnDepth(1))
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	expected = ";C1;C1 | D1;C1 | D2;C2;Total\nA1;6;4;2;;6\nA1 | B1;4;4;;;4\nA1 | B2;2;;2;;2\nA2;;;;4;4\nTotal;6;4;2;4;10\n"
+	result = table.ToCSV(CollapseRows(HeaderPath{"A2"}), CollapseColumns(HeaderPath{"C2"}))
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	expected = ";Total\nTotal;10\n"
+	result = table.ToCSV(CollapseRows(HeaderPath{}), CollapseColumns(HeaderPath{}))
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+}
+
+func TestToRecords(t *testing.T) {
+	rawData := [][]interface{}{
+		{"Customer", "Product", "Amount"},
+		{"C1", "P1", 4},
+		{"C1", "P2", 2},
+		{"C2", "P1", 3},
+		{"C1", "P1", 1},
+	}
+	table := NewTable(rawData, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0)).
+		TextValues(1, Mode)
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	records := table.ToRecords()
+	expected := "[[Customer Product Series Value] [C1 P1 Amount 5] [C1 P1 Product P1] [C1 P2 Amount 2] [C1 P2 Product P2] [C2 P1 Amount 3] [C2 P1 Product P1]]"
+	if fmt.Sprint(records) != expected {
+		t.Fatalf("records=%v!=%s", records, expected)
+	}
+	totals := NewTable(records, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		Filter(2, Equals("Amount")).
+		Values(3, Sum, Digits(0))
+	err = totals.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";Total\nC1;7\nC2;3\nTotal;10\n"
+	result := totals.ToCSV()
+	if result != expected {
+		t.Fatalf("totals.ToCSV()=%s!=%s", result, expected)
+	}
+	rawData = append(rawData, []interface{}{"C2", "P2", ""})
+	table = NewTable(rawData, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0)).
+		EmptyValues(SkipEmpty)
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	records = table.ToRecords()
+	expected = "[[Customer Product Series Value] [C1 P1 Amount 5] [C1 P2 Amount 2] [C2 P1 Amount 3] [C2 P2 Amount <nil>]]"
+	if fmt.Sprint(records) != expected {
+		t.Fatalf("records=%v!=%s", records, expected)
+	}
+	totals = NewTable(records, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		Values(3, Sum, Digits(0)).
+		EmptyValues(SkipEmpty)
+	err = totals.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";Total\nC1;7\nC2;3\nTotal;10\n"
+	result = totals.ToCSV()
+	if result != expected {
+		t.Fatalf("totals.ToCSV()=%s!=%s", result, expected)
+	}
+}
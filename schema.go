@@ -0,0 +1,178 @@
+package pivot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ColumnType int
+
+const (
+	StringColumn ColumnType = iota
+	Int64Column
+	Float64Column
+	DecimalColumn
+	BoolColumn
+	TimeColumn
+)
+
+// ColumnSchema declares the type of an input column, Layout is used by TimeColumn only
+type ColumnSchema struct {
+	Type   ColumnType
+	Layout string
+}
+
+// Schema declares input column types by index
+type Schema []ColumnSchema
+
+var inferredTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", "2006/01/02"}
+
+// InferSchema guesses column types from the first sampleSize records (all if sampleSize <= 0).
+// Empty values are ignored, columns with only empty values are strings. Decimals are never inferred.
+func InferSchema(data [][]interface{}, dataHeaders bool, sampleSize int) Schema {
+	if len(data) == 0 {
+		return nil
+	}
+	records := data
+	if dataHeaders {
+		records = data[1:]
+	}
+	if sampleSize > 0 && len(records) > sampleSize {
+		records = records[:sampleSize]
+	}
+	schema := make(Schema, len(data[0]))
+	for i := range schema {
+		schema[i] = inferColumn(records, i)
+	}
+	return schema
+}
+
+func inferColumn(records [][]interface{}, index int) ColumnSchema {
+	candidates := []ColumnSchema{{Type: Int64Column}, {Type: Float64Column}, {Type: BoolColumn}}
+	for _, layout := range inferredTimeLayouts {
+		candidates = append(candidates, ColumnSchema{Type: TimeColumn, Layout: layout})
+	}
+	found := false
+	for _, record := range records {
+		if IsEmpty(record[index]) {
+			continue
+		}
+		found = true
+		var kept []ColumnSchema
+		for _, candidate := range candidates {
+			if _, err := candidate.parse(record[index], nil); err == nil {
+				kept = append(kept, candidate)
+			}
+		}
+		candidates = kept
+	}
+	if !found || len(candidates) == 0 {
+		return ColumnSchema{Type: StringColumn}
+	}
+	return candidates[0]
+}
+
+func (c ColumnSchema) parse(element RawValue, locale *Locale) (RawValue, error) {
+	switch c.Type {
+	case Int64Column:
+		switch element.(type) {
+		case int, int64, string:
+			return toInt64(element, locale)
+		}
+	case Float64Column:
+		switch element.(type) {
+		case int, int64, float64, string:
+			return toLocaleFloat(element, locale)
+		}
+	case DecimalColumn:
+		switch e := element.(type) {
+		case Decimal:
+			return e, nil
+		case int:
+			return NewDecimal(int64(e), 0), nil
+		case int64:
+			return NewDecimal(e, 0), nil
+		case string:
+			return parseLocaleDecimal(e, locale)
+		}
+	case BoolColumn:
+		switch e := element.(type) {
+		case bool:
+			return e, nil
+		case string:
+			return strconv.ParseBool(strings.TrimSpace(e))
+		}
+	case TimeColumn:
+		return toTime(element, c.Layout)
+	default:
+		return toString(element), nil
+	}
+	return nil, InvalidType(element)
+}
+
+// Parse returns a copy of data where each non-empty element is converted to its column type
+func (s Schema) Parse(data [][]interface{}, dataHeaders bool) ([][]interface{}, error) {
+	return s.parse(data, dataHeaders, nil)
+}
+
+func (s Schema) parse(data [][]interface{}, dataHeaders bool, locale *Locale) ([][]interface{}, error) {
+	result := make([][]interface{}, len(data))
+	for i, record := range data {
+		if i == 0 && dataHeaders {
+			result[i] = record
+			continue
+		}
+		parsed, column, err := s.parseRecord(record, locale)
+		if err != nil {
+			return nil, newRecordError(i, column, record, err)
+		}
+		result[i] = parsed
+	}
+	return result, nil
+}
+
+// parseRecord returns the parsed record, or the index of the faulty element with the parsing error
+func (s Schema) parseRecord(record []interface{}, locale *Locale) ([]interface{}, int, error) {
+	if len(record) != len(s) {
+		return nil, -1, fmt.Errorf("record has %d elements while schema has %d columns", len(record), len(s))
+	}
+	parsed := make([]interface{}, len(record))
+	for j, element := range record {
+		if IsEmpty(element) {
+			parsed[j] = element
+			continue
+		}
+		value, err := s[j].parse(element, locale)
+		if err != nil {
+			return nil, j, err
+		}
+		parsed[j] = value
+	}
+	return parsed, -1, nil
+}
+
+// ReadCSV reads CSV input into records converted with schema, or kept as strings when schema is nil. Use
+// Table.InferSchema to convert numeric value columns only, labels being kept as written.
+func ReadCSV(r io.Reader, comma rune, dataHeaders bool, schema Schema) ([][]interface{}, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("while reading CSV: %w", err)
+	}
+	data := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		data[i] = make([]interface{}, len(row))
+		for j, element := range row {
+			data[i][j] = element
+		}
+	}
+	if schema == nil {
+		return data, nil
+	}
+	return schema.Parse(data, dataHeaders)
+}
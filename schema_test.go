@@ -0,0 +1,112 @@
+package pivot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferSchema(t *testing.T) {
+	input := "Name;Count;Amount;Active;Date;Region\n" +
+		"A1;1;12345678,91;true;2022-01-15;B1\n" +
+		"A2;;0,09;false;;B2\n" +
+		"A1;3;10;true;2022-02-15;B2\n"
+	var rawData [][]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(input), "\n") {
+		var record []interface{}
+		for _, element := range strings.Split(line, ";") {
+			record = append(record, element)
+		}
+		rawData = append(rawData, record)
+	}
+	expected := Schema{{Type: StringColumn}, {Type: Int64Column}, {Type: Float64Column}, {Type: BoolColumn}, {Type: TimeColumn, Layout: "2006-01-02"}, {Type: StringColumn}}
+	schema := InferSchema(rawData, true, 0)
+	for i := range expected {
+		if schema[i] != expected[i] {
+			t.Fatalf("schema[%d]=%v!=%v", i, schema[i], expected[i])
+		}
+	}
+	data, err := ReadCSV(strings.NewReader(input), ';', true, schema)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if data[1][1] != int64(1) || data[2][1] != "" || data[3][4] != time.Date(2022, 2, 15, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("unexpected parsed data %v", data)
+	}
+	table := NewTable(data, true).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{5}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(2))
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expectedCSV := ";B1;B2;Total\nA1;12345678.91;10.00;12345688.91\nA2;;0.09;0.09\nTotal;12345678.91;10.09;12345689.00\n"
+	if table.ToCSV() != expectedCSV {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expectedCSV)
+	}
+}
+
+func TestSchemaParse(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "1,50", "x"},
+	}
+	_, err := Schema{{Type: StringColumn}, {Type: DecimalColumn}, {Type: Int64Column}}.Parse(rawData, false)
+	if err == nil {
+		t.Fatalf("expected error while parsing %v", rawData)
+	}
+	data, err := Schema{{Type: StringColumn}, {Type: DecimalColumn}, {Type: StringColumn}}.Parse(rawData, false)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if data[0][1] != NewDecimal(150, 2) {
+		t.Fatalf("data[0][1]=%v!=1.50", data[0][1])
+	}
+}
+
+func TestSchemaLocale(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", "1.234"},
+		{"A1", "B1", "2"},
+	}
+	// 1234+2 rendered with EU thousands separator
+	expected := ";B1;Total\nA1;1.236;1.236\nTotal;1.236;1.236\n"
+	schema := Schema{{Type: StringColumn}, {Type: StringColumn}, {Type: Int64Column}}
+	for _, table := range []*Table[int64]{
+		NewTableOf[int64](rawData, false).Schema(schema),
+		NewTableOf[int64](rawData, false),
+	} {
+		err := table.Row(0).Column(1).Values(2, Sum, "%d").Locale(LocaleEU).Generate()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		result := table.ToCSV()
+		if result != expected {
+			t.Fatalf("table.ToCSV()=%q!=%q", result, expected)
+		}
+	}
+}
+
+func TestInferSchemaLabels(t *testing.T) {
+	input := "Code;Date;Amount\n" +
+		"00123;2024-01-02;1,5\n" +
+		"00124;2024-01-03;2\n" +
+		"00123;2024-01-03;3\n"
+	data, err := ReadCSV(strings.NewReader(input), ';', true, nil)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	table := NewTable(data, true).
+		InferSchema(0).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(1))
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";2024-01-02;2024-01-03;Total\n00123;1.5;3.0;4.5\n00124;;2.0;2.0\nTotal;1.5;5.0;6.5\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+}
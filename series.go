@@ -1,6 +1,9 @@
 package pivot
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type Operation int
 
@@ -8,11 +11,74 @@ const (
 	none Operation = iota
 	Count
 	Sum
+	text
+	weightedSum
+	Average
+	Min
+	Max
+	weights
+)
+
+type EmptyPolicy int
+
+const (
+	// DefaultEmpty applies the table policy, see Table.EmptyValues
+	DefaultEmpty EmptyPolicy = iota
+	// EmptyAsZero records empty values as zero, so that they are counted
+	EmptyAsZero
+	// SkipEmpty ignores empty values, so that Count and Average exclude them
+	SkipEmpty
+	// FailOnEmpty stops generation on the first empty value
+	FailOnEmpty
 )
 
 type DataRef struct {
 	index     int
 	operation Operation
+	weight    int
+	empty     EmptyPolicy
+}
+
+func sortedDataRefs(dataRefs map[DataRef]bool) []DataRef {
+	result := make([]DataRef, 0, len(dataRefs))
+	for k := range dataRefs {
+		result = append(result, k)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.index != b.index {
+			return a.index < b.index
+		}
+		if a.operation != b.operation {
+			return a.operation < b.operation
+		}
+		if a.weight != b.weight {
+			return a.weight < b.weight
+		}
+		return a.empty < b.empty
+	})
+	return result
+}
+
+func Ref(index int, operation Operation) DataRef {
+	return DataRef{index: index, operation: operation}
+}
+
+// OnEmpty returns a copy of the data reference handling empty values with given policy
+func (d DataRef) OnEmpty(policy EmptyPolicy) DataRef {
+	d.empty = policy
+	return d
+}
+
+// Weighted sums the products of values at valueIndex by weights at weightIndex
+func Weighted(valueIndex, weightIndex int) DataRef {
+	return DataRef{index: valueIndex, operation: weightedSum, weight: weightIndex}
+}
+
+// Weights sums the weights at weightIndex of records with a value at valueIndex, skipping with Weighted the records
+// whose value is empty under SkipEmpty
+func Weights(valueIndex, weightIndex int) DataRef {
+	return DataRef{index: valueIndex, operation: weights, weight: weightIndex}
 }
 
 func DataRefs(indexes []int, operation Operation) []DataRef {
@@ -24,15 +90,26 @@ func DataRefs(indexes []int, operation Operation) []DataRef {
 	return dataRefs
 }
 
-type seriesType interface{ string | float64 }
+type seriesType interface{ string | valueType }
 
 type series[T seriesType] struct {
-	dataRefs []DataRef
-	name     string
-	filter   Filter
-	compute  Compute[T]
-	sort     Sort
-	format   string
+	dataRefs  []DataRef
+	refs      []int
+	name      string
+	filter    Filter
+	compute   Compute[T]
+	aggregate TextAggregate
+	sort      Sort
+	format    string
+	names     functionNames
+}
+
+// functionNames are the registry names of series functions, empty for functions given by value
+type functionNames struct {
+	filter    string
+	compute   string
+	sort      string
+	aggregate string
 }
 
 func newRCSeries(dataIndexes []int, filter Filter, compute Compute[string], sort Sort) *series[string] {
@@ -48,8 +125,8 @@ func newRCSeries(dataIndexes []int, filter Filter, compute Compute[string], sort
 	}
 }
 
-func newVSeries(name SeriesName, dataRefs []DataRef, compute Compute[float64], format ValueFormat) *series[float64] {
-	return &series[float64]{
+func newVSeries[T valueType](name SeriesName, dataRefs []DataRef, compute Compute[T], format ValueFormat) *series[T] {
+	return &series[T]{
 		dataRefs: dataRefs,
 		name:     string(name),
 		compute:  compute,
@@ -65,6 +142,14 @@ func toIndexes(dataRefs []DataRef) []int {
 	return result
 }
 
+// column returns the input index the series is based on, -1 if it is computed from several indexes
+func (s *series[T]) column() int {
+	if len(s.dataRefs) != 1 {
+		return -1
+	}
+	return s.dataRefs[0].index
+}
+
 func (s *series[T]) NameFromHeaders(headers []interface{}) {
 	if s.compute != nil {
 		if len(s.dataRefs) > 0 && len(s.name) == 0 {
@@ -0,0 +1,437 @@
+package pivot
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+)
+
+const snapshotVersion = 1
+
+// Registry knows functions by name, so that tables defined with named functions can be snapshotted and merged
+type Registry struct {
+	functions map[string]interface{}
+	err       error
+}
+
+// NewRegistry returns a registry knowing builtin sorts, filters, computes and text aggregates under their variable name
+func NewRegistry() *Registry {
+	r := &Registry{functions: make(map[string]interface{})}
+	return r.
+		Register("AlphaSort", AlphaSort).
+		Register("ReverseAlphaSort", ReverseAlphaSort).
+		Register("MonthSort", MonthSort).
+		Register("SumFloats", SumFloats).
+		Register("SumDecimals", SumDecimals).
+		Register("IsEmpty", IsEmpty).
+		Register("NotBlank", NotBlank).
+		Register("Mode", Mode).
+		Register("MinString", MinString).
+		Register("MaxString", MaxString).
+		Register("FirstString", FirstString).
+		Register("LastString", LastString)
+}
+
+// Register names function, a Sort, Filter, RecordFilter, Compute or TextAggregate, for tables using this registry
+func (r *Registry) Register(name string, function interface{}) *Registry {
+	v := reflect.ValueOf(function)
+	if (len(name) == 0 || v.Kind() != reflect.Func || v.IsNil()) && r.err == nil {
+		r.err = fmt.Errorf("invalid registration of %q, not a named function", name)
+	}
+	if _, ok := r.functions[name]; ok && r.err == nil {
+		r.err = fmt.Errorf("invalid registration of %q, name already used", name)
+	}
+	r.functions[name] = function
+	return r
+}
+
+// nameOf returns the name function was defined by, "" if nil
+func nameOf(function interface{}, name string, what string) (string, error) {
+	if reflect.ValueOf(function).IsNil() {
+		return "", nil
+	}
+	if len(name) == 0 {
+		return "", fmt.Errorf("unnamed function for %s, define it by name with Functions", what)
+	}
+	return name, nil
+}
+
+func lookup[F any](r *Registry, name string) (F, error) {
+	var result F
+	if len(name) == 0 {
+		return result, nil
+	}
+	function, ok := r.functions[name]
+	if !ok {
+		return result, fmt.Errorf("unregistered function %q", name)
+	}
+	result, ok = function.(F)
+	if !ok {
+		return result, fmt.Errorf("invalid function %q, %T is not a %T", name, function, result)
+	}
+	return result, nil
+}
+
+type snapshot[T valueType] struct {
+	Version         int
+	Type            string
+	DataHeaders     bool
+	Headers         []string
+	Schema          Schema
+	Locale          *Locale
+	Absent          string
+	Invalid         *string
+	EmptyPolicy     EmptyPolicy
+	BlankLabel      string
+	MaxRecordErrors int
+	PageIndex       int
+	Filters         map[int]string
+	RecordFilters   []string
+	Rows            []rcSnapshot
+	Columns         []rcSnapshot
+	Values          []valueSnapshot
+	State           *stateSnapshot[T]
+	Pages           map[string]*stateSnapshot[T]
+}
+
+type rcSnapshot struct {
+	Name    string
+	Indexes []int
+	Filter  string
+	Compute string
+	Sort    string
+}
+
+type valueSnapshot struct {
+	Name      string
+	DataRefs  []dataRefSnapshot
+	Compute   string
+	Aggregate string
+	Format    string
+}
+
+type dataRefSnapshot struct {
+	Index     int
+	Operation Operation
+	Weight    int
+	Empty     EmptyPolicy
+}
+
+// stateSnapshot holds what Generate computes, headers being listed parents first
+type stateSnapshot[T valueType] struct {
+	RowHeaders    []headerSnapshot
+	ColumnHeaders []headerSnapshot
+	Cells         []cellSnapshot[T]
+	Stats         Stats
+}
+
+type headerSnapshot struct {
+	Parent int
+	Label  string
+}
+
+type cellSnapshot[T valueType] struct {
+	Row     int
+	Column  int
+	Values  []T
+	Counts  []int64
+	Texts   [][]string
+	Records int64
+}
+
+func typeName[T valueType]() string {
+	var zero T
+	return fmt.Sprintf("%T", zero)
+}
+
+// Snapshot writes a generated table, without its input data, so that it can be loaded back with LoadSnapshot to be
+// rendered, merged or updated with Add. Functions of the table definition must be builtins or returned by a Registry.
+func (t *Table[T]) Snapshot(w io.Writer) error {
+	if t.err != nil {
+		return t.err
+	}
+	if t.layout == nil {
+		return fmt.Errorf("table not generated")
+	}
+	s := snapshot[T]{
+		Version:         snapshotVersion,
+		Type:            typeName[T](),
+		DataHeaders:     t.dataHeaders,
+		Schema:          t.schema,
+		Locale:          t.display.locale,
+		Absent:          t.display.absent,
+		Invalid:         t.display.invalid,
+		EmptyPolicy:     t.emptyPolicy,
+		BlankLabel:      t.blankLabel,
+		MaxRecordErrors: t.maxRecordErrors,
+		PageIndex:       t.pageIndex,
+		Filters:         make(map[int]string),
+	}
+	if t.dataHeaders {
+		for _, header := range t.data[0] {
+			s.Headers = append(s.Headers, toString(header))
+		}
+	}
+	var err error
+	for index, filter := range t.filters {
+		s.Filters[index], err = nameOf(filter, t.filterNames[index], fmt.Sprintf("filter on index %d", index))
+		if err != nil {
+			return err
+		}
+	}
+	for i, filter := range t.recordFilters {
+		name, err := nameOf(filter, t.recordFilterNames[i], fmt.Sprintf("record filter %d", i))
+		if err != nil {
+			return err
+		}
+		s.RecordFilters = append(s.RecordFilters, name)
+	}
+	s.Rows, err = rcSnapshots(t.rowSeries)
+	if err != nil {
+		return err
+	}
+	s.Columns, err = rcSnapshots(t.columnSeries)
+	if err != nil {
+		return err
+	}
+	for _, serie := range t.valueSeries {
+		v := valueSnapshot{Name: serie.name, Format: serie.format}
+		v.Compute, err = nameOf(serie.compute, serie.names.compute, fmt.Sprintf("value %q", serie.name))
+		if err != nil {
+			return err
+		}
+		v.Aggregate, err = nameOf(serie.aggregate, serie.names.aggregate, fmt.Sprintf("value %q", serie.name))
+		if err != nil {
+			return err
+		}
+		for _, k := range serie.dataRefs {
+			v.DataRefs = append(v.DataRefs, dataRefSnapshot{Index: k.index, Operation: k.operation, Weight: k.weight, Empty: k.empty})
+		}
+		s.Values = append(s.Values, v)
+	}
+	s.State = t.state()
+	if t.pages != nil {
+		s.Pages = make(map[string]*stateSnapshot[T], len(t.pages))
+		for label, page := range t.pages {
+			s.Pages[label] = page.state()
+		}
+	}
+	return gob.NewEncoder(w).Encode(&s)
+}
+
+func rcSnapshots(series []*series[string]) ([]rcSnapshot, error) {
+	var result []rcSnapshot
+	for _, serie := range series {
+		rc := rcSnapshot{Name: serie.name, Indexes: toIndexes(serie.dataRefs)}
+		var err error
+		what := fmt.Sprintf("serie %q", serie.name)
+		rc.Filter, err = nameOf(serie.filter, serie.names.filter, what)
+		if err != nil {
+			return nil, err
+		}
+		rc.Compute, err = nameOf(serie.compute, serie.names.compute, what)
+		if err != nil {
+			return nil, err
+		}
+		rc.Sort, err = nameOf(serie.sort, serie.names.sort, what)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rc)
+	}
+	return result, nil
+}
+
+func (t *Table[T]) state() *stateSnapshot[T] {
+	s := &stateSnapshot[T]{Stats: *t.stats}
+	var rowIds, columnIds map[int]int
+	s.RowHeaders, rowIds = headerSnapshots(t.rowHeaders)
+	s.ColumnHeaders, columnIds = headerSnapshots(t.columnHeaders)
+	for key, c := range t.cells {
+		p := c.(*pivotCell[T])
+		s.Cells = append(s.Cells, cellSnapshot[T]{
+			Row:     rowIds[key.row],
+			Column:  columnIds[key.column],
+			Values:  p.recordedValues,
+			Counts:  p.recordedCounts,
+			Texts:   p.recordedTexts,
+			Records: p.records,
+		})
+	}
+	return s
+}
+
+// headerSnapshots lists headers still attached to root in id order, it returns the positions of their ids. Header
+// sorts are those of the series, they are not listed.
+func headerSnapshots(root *headers) ([]headerSnapshot, map[int]int) {
+	var result []headerSnapshot
+	positions := make(map[int]int)
+	for _, h := range root.tree.nodes {
+		parent := -1
+		if h.parent != nil {
+			position, ok := positions[h.parent.id]
+			if !ok || h.parent.elements[h.label] != h {
+				continue
+			}
+			parent = position
+		}
+		positions[h.id] = len(result)
+		result = append(result, headerSnapshot{Parent: parent, Label: h.label})
+	}
+	return result, positions
+}
+
+// LoadSnapshot reads a table written by Snapshot, functions of its definition being looked up by name in registry
+func LoadSnapshot[T valueType](r io.Reader, registry *Registry) (*Table[T], error) {
+	if registry.err != nil {
+		return nil, registry.err
+	}
+	var s snapshot[T]
+	err := gob.NewDecoder(r).Decode(&s)
+	if err != nil {
+		return nil, fmt.Errorf("while decoding snapshot: %w", err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	if s.Type != typeName[T]() {
+		return nil, fmt.Errorf("invalid snapshot of %s values, expected %s", s.Type, typeName[T]())
+	}
+	var data [][]interface{}
+	if s.DataHeaders {
+		headers := make([]interface{}, len(s.Headers))
+		for i, header := range s.Headers {
+			headers[i] = header
+		}
+		data = append(data, headers)
+	}
+	t := newTableOf[T](data, s.DataHeaders)
+	t.schema = s.Schema
+	t.display.locale = s.Locale
+	t.display.absent = s.Absent
+	t.display.invalid = s.Invalid
+	t.emptyPolicy = s.EmptyPolicy
+	t.blankLabel = s.BlankLabel
+	t.maxRecordErrors = s.MaxRecordErrors
+	t.pageIndex = s.PageIndex
+	if t.pageIndex >= 0 {
+		t.registeredRCIndexes[t.pageIndex] = true
+	}
+	t.Functions(registry)
+	for index, name := range s.Filters {
+		t.FilterBy(index, name)
+	}
+	for _, name := range s.RecordFilters {
+		t.FilterRecordsBy(name)
+	}
+	for _, rc := range s.Rows {
+		t.NamedRow(rc.Indexes, rc.Filter, rc.Compute, rc.Sort)
+		if t.err == nil {
+			t.rowSeries[len(t.rowSeries)-1].name = rc.Name
+		}
+	}
+	for _, rc := range s.Columns {
+		t.NamedColumn(rc.Indexes, rc.Filter, rc.Compute, rc.Sort)
+		if t.err == nil {
+			t.columnSeries[len(t.columnSeries)-1].name = rc.Name
+		}
+	}
+	for _, v := range s.Values {
+		dataRefs := make([]DataRef, len(v.DataRefs))
+		for i, k := range v.DataRefs {
+			dataRefs[i] = DataRef{index: k.Index, operation: k.Operation, weight: k.Weight, empty: k.Empty}
+		}
+		t.NamedValues(v.Name, dataRefs, v.Compute, v.Format)
+		if t.err == nil && len(v.Aggregate) > 0 {
+			serie := t.valueSeries[len(t.valueSeries)-1]
+			serie.aggregate = lookupFunction[TextAggregate](t, v.Aggregate)
+			serie.names.aggregate = v.Aggregate
+		}
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	t.layout = t.newLayout()
+	t.logger = slog.New(discardHandler{})
+	err = t.load(s.State)
+	if err != nil {
+		return nil, err
+	}
+	if s.Pages != nil {
+		t.pages = make(map[string]*Table[T], len(s.Pages))
+		for label, state := range s.Pages {
+			page := t.spawn(nil)
+			page.layout = page.newLayout()
+			page.logger = t.logger
+			err = page.load(state)
+			if err != nil {
+				return nil, fmt.Errorf("while loading page %q: %w", label, err)
+			}
+			t.pages[label] = page
+		}
+	}
+	return t, nil
+}
+
+// load restores generated state into t, whose layout is set
+func (t *Table[T]) load(s *stateSnapshot[T]) error {
+	stats := s.Stats
+	t.stats = newStats(len(t.recordFilters))
+	// gob decodes empty maps and slices as nil
+	if stats.FilteredByIndex == nil {
+		stats.FilteredByIndex = t.stats.FilteredByIndex
+	}
+	if stats.FilteredByRecordFilter == nil {
+		stats.FilteredByRecordFilter = t.stats.FilteredByRecordFilter
+	}
+	if stats.FilteredBySeries == nil {
+		stats.FilteredBySeries = t.stats.FilteredBySeries
+	}
+	if stats.EmptyValues == nil {
+		stats.EmptyValues = t.stats.EmptyValues
+	}
+	t.stats = &stats
+	rows, err := loadHeaders(t.rowHeaders, s.RowHeaders, t.rowSeries)
+	if err != nil {
+		return err
+	}
+	columns, err := loadHeaders(t.columnHeaders, s.ColumnHeaders, t.columnSeries)
+	if err != nil {
+		return err
+	}
+	for _, c := range s.Cells {
+		if c.Row >= len(rows) || c.Column >= len(columns) || len(c.Values) != len(t.layout.dataRefs) || len(c.Counts) != len(t.layout.dataRefs) {
+			return fmt.Errorf("invalid snapshot cell")
+		}
+		p := t.newCell(t.layout).(*pivotCell[T])
+		copy(p.recordedValues, c.Values)
+		copy(p.recordedCounts, c.Counts)
+		if c.Texts != nil {
+			p.recordedTexts = c.Texts
+		}
+		p.records = c.Records
+		t.cells[cellKey{row: rows[c.Row].id, column: columns[c.Column].id}] = p
+	}
+	return t.finalize()
+}
+
+// loadHeaders restores headers under root, sorting the children of those at depth d like series d does
+func loadHeaders(root *headers, snapshots []headerSnapshot, series []*series[string]) ([]*headers, error) {
+	result := make([]*headers, len(snapshots))
+	for i, h := range snapshots {
+		if i == 0 {
+			result[i] = root
+		} else {
+			if h.Parent < 0 || h.Parent >= i {
+				return nil, fmt.Errorf("invalid snapshot header %q", h.Label)
+			}
+			result[i] = result[h.Parent].walk(h.Label)
+		}
+		if result[i].depth < len(series) {
+			result[i].sort(series[result[i].depth].sort)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package pivot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "P", "V", "T"},
+		{"A1", "B1", "P1", "1.50", "T1"},
+		{"A1", "B2", "P2", "2.25", "T2"},
+		{"A2", "B1", "P1", "3.00", "T1"},
+		{"", "B2", "P1", "4.00", "T3"},
+		{"A2", "B2", "P2", "0.75", "T2"},
+		{"A1", "B1", "P1", "0.50", "T2"},
+	}
+	registry := NewRegistry().Register("ratio", Ratio[Decimal](ZeroAsEmpty)).Register("list", List(","))
+	table := NewDecimalTable(rawData[:5], true).
+		Functions(registry).
+		NamedRow([]int{0}, "NotBlank", "", "AlphaSort").
+		NamedColumn([]int{1}, "", "", "ReverseAlphaSort").
+		Page(2).
+		Values(3, Sum, Digits(2)).
+		NamedValues("Ratio", []DataRef{Ref(3, Sum), Ref(3, Count)}, "ratio", Digits(2)).
+		NamedTextValues(4, "Mode").
+		NamedTextValues(4, "list")
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	var buffer bytes.Buffer
+	err = table.Snapshot(&buffer)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	loaded, err := LoadSnapshot[Decimal](&buffer, registry)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B2;B1;Total\n" +
+		"A1;[ 2.25, 2.25, T2, T2 ];[ 1.50, 1.50, T1, T1 ];[ 3.75, 1.88, T1, T1,T2 ]\n" +
+		"A2;;[ 3.00, 3.00, T1, T1 ];[ 3.00, 3.00, T1, T1 ]\n" +
+		"Total;[ 2.25, 2.25, T2, T2 ];[ 4.50, 2.25, T1, T1,T1 ];[ 6.75, 2.25, T1, T1,T2,T1 ]\n"
+	if loaded.ToCSV() != expected {
+		t.Fatalf("loaded.ToCSV()=%q!=%q", loaded.ToCSV(), expected)
+	}
+	err = loaded.Add(rawData[5:]...)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";B2;B1;Total\n" +
+		"A1;[ 2.25, 2.25, T2, T2 ];[ 2.00, 1.00, T1, T1,T2 ];[ 4.25, 1.42, T2, T1,T2,T2 ]\n" +
+		"A2;[ 0.75, 0.75, T2, T2 ];[ 3.00, 3.00, T1, T1 ];[ 3.75, 1.88, T1, T1,T2 ]\n" +
+		"Total;[ 3.00, 1.50, T2, T2,T2 ];[ 5.00, 1.67, T1, T1,T1,T2 ];[ 8.00, 1.60, T2, T1,T2,T1,T2,T2 ]\n"
+	if loaded.ToCSV() != expected {
+		t.Fatalf("loaded.ToCSV()=%q!=%q", loaded.ToCSV(), expected)
+	}
+	expected = ";B1;Total\n" +
+		"A1;[ 2.00, 1.00, T1, T1,T2 ];[ 2.00, 1.00, T1, T1,T2 ]\n" +
+		"A2;[ 3.00, 3.00, T1, T1 ];[ 3.00, 3.00, T1, T1 ]\n" +
+		"Total;[ 5.00, 1.67, T1, T1,T1,T2 ];[ 5.00, 1.67, T1, T1,T1,T2 ]\n"
+	if loaded.Pages()["P1"].ToCSV() != expected {
+		t.Fatalf("loaded.Pages()[P1].ToCSV()=%q!=%q", loaded.Pages()["P1"].ToCSV(), expected)
+	}
+	unnamed := NewDecimalTable(rawData, true).Row(0).ComputedValues("Ratio", []DataRef{Ref(3, Sum), Ref(3, Count)}, Ratio[Decimal](ZeroAsEmpty), Digits(2))
+	err = unnamed.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = unnamed.Snapshot(&buffer)
+	if err == nil {
+		t.Fatalf("expected error with unnamed compute")
+	}
+	buffer.Reset()
+	err = table.Snapshot(&buffer)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	_, err = LoadSnapshot[Decimal](&buffer, NewRegistry())
+	if err == nil {
+		t.Fatalf("expected error with unregistered compute")
+	}
+	buffer.Reset()
+	err = table.Snapshot(&buffer)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	_, err = LoadSnapshot[float64](&buffer, registry)
+	if err == nil {
+		t.Fatalf("expected error loading decimal snapshot as float64")
+	}
+}
@@ -0,0 +1,59 @@
+package pivot
+
+import "time"
+
+// Stats describes the last Generate run, a record rejected by several filters is counted for each of them
+type Stats struct {
+	Records                int
+	Generated              int
+	Skipped                int
+	FilteredByIndex        map[int]int
+	FilteredByRecordFilter []int
+	FilteredBySeries       map[string]int
+	EmptyValues            map[int]int
+	RowLabels              []int
+	ColumnLabels           []int
+	Cells                  int
+	FilterDuration         time.Duration
+	WalkDuration           time.Duration
+	AggregateDuration      time.Duration
+}
+
+func newStats(recordFilters int) *Stats {
+	return &Stats{
+		FilteredByIndex:        make(map[int]int),
+		FilteredByRecordFilter: make([]int, recordFilters),
+		FilteredBySeries:       make(map[string]int),
+		EmptyValues:            make(map[int]int),
+	}
+}
+
+// unfilter takes back the records counted as filtered in other
+func (s *Stats) unfilter(other *Stats) {
+	for index, count := range other.FilteredByIndex {
+		s.FilteredByIndex[index] -= count
+	}
+	for i, count := range other.FilteredByRecordFilter {
+		s.FilteredByRecordFilter[i] -= count
+	}
+	for name, count := range other.FilteredBySeries {
+		s.FilteredBySeries[name] -= count
+	}
+}
+
+// levelSizes returns the number of distinct labels at each depth below h
+func levelSizes(h *headers, depth int) []int {
+	sizes := make([]int, depth)
+	var count func(h *headers, level int)
+	count = func(h *headers, level int) {
+		if level >= depth {
+			return
+		}
+		sizes[level] += len(h.elements)
+		for _, child := range h.elements {
+			count(child, level+1)
+		}
+	}
+	count(h, 0)
+	return sizes
+}
@@ -2,7 +2,11 @@ package pivot
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 type RawValue interface{}
@@ -15,41 +19,85 @@ type SeriesName string
 
 type Filter func(RawValue) bool
 
+type RecordFilter func([]interface{}) bool
+
 type Sort func([]Header) []Header
 
 type Compute[T seriesType] func([]RawValue) (T, error)
 
-type valueType interface{ float64 }
+// TextAggregate reduces the non-empty texts recorded in a cell, in records order
+type TextAggregate func([]string) string
+
+type valueType interface {
+	float64 | int64 | uint64 | Decimal
+}
+
+// fractionalType are value types whose divisions are not truncated
+type fractionalType interface {
+	float64 | Decimal
+}
 
-type converter[T valueType] func(RawValue) (T, error)
+type converter[T valueType] func(RawValue, *Locale) (T, error)
 
 type vSeriesFactory[T valueType] func(SeriesName, []DataRef, Compute[T], ValueFormat) *series[T]
 
-type cellFactory[T valueType] func([]ValueFormat) cell[T]
+type cellFactory[T valueType] func(*layout[T]) cell[T]
 
 // Table
 // usedIndexes to avoid declaring same index as row & column
 type Table[T valueType] struct {
 	data                [][]interface{}
+	ownData             bool
 	dataHeaders         bool
 	registeredRCIndexes map[int]bool
 	registeredVIndexes  map[DataRef]bool
-	cells               map[string]map[string]cell[T]
+	layout              *layout[T]
+	cells               map[cellKey]cell[T]
 	filters             map[int]Filter
+	filterNames         map[int]string
+	recordFilters       []RecordFilter
+	recordFilterNames   []string
+	registry            *Registry
 	rowHeaders          *headers
 	columnHeaders       *headers
 	valueHeaders        *headers
 	rowSeries           []*series[string]
 	columnSeries        []*series[string]
 	valueSeries         []*series[T]
+	schema              Schema
+	infer               bool
+	inferSize           int
+	display             *display
+	emptyPolicy         EmptyPolicy
+	blankLabel          string
+	maxRecordErrors     int
+	recordErrors        []*RecordError
+	logger              *slog.Logger
+	dirty               map[cellKey]bool
+	drillDown           bool
+	drill               map[cellKey][]int
+	stats               *Stats
+	pageIndex           int
+	pages               map[string]*Table[T]
 	series              map[int]*series[T]
 	newVSeries          vSeriesFactory[T]
 	newCell             cellFactory[T]
 	cellValue           converter[T]
+	arithmetic          arithmetic[T]
 	err                 error
 }
 
 func NewTable(data [][]interface{}, dataHeaders bool) *Table[float64] {
+	return NewTableOf[float64](data, dataHeaders)
+}
+
+// NewDecimalTable aggregates values as fixed-point decimals, so that sums of amounts do not drift
+func NewDecimalTable(data [][]interface{}, dataHeaders bool) *Table[Decimal] {
+	return NewTableOf[Decimal](data, dataHeaders)
+}
+
+// NewTableOf aggregates values as T, use int64 or uint64 for exact counters
+func NewTableOf[T valueType](data [][]interface{}, dataHeaders bool) *Table[T] {
 	var err error
 	if data == nil || (len(data) == 0 && !dataHeaders) || (len(data) <= 1 && dataHeaders) {
 		err = fmt.Errorf("no input data")
@@ -65,80 +113,211 @@ func NewTable(data [][]interface{}, dataHeaders bool) *Table[float64] {
 			}
 		}
 	}
-	return &Table[float64]{
+	t := newTableOf[T](data, dataHeaders)
+	t.err = err
+	return t
+}
+
+func newTableOf[T valueType](data [][]interface{}, dataHeaders bool) *Table[T] {
+	return &Table[T]{
 		data:                data,
 		dataHeaders:         dataHeaders,
 		registeredRCIndexes: make(map[int]bool),
 		registeredVIndexes:  make(map[DataRef]bool),
-		cells:               make(map[string]map[string]cell[float64]),
+		cells:               make(map[cellKey]cell[T]),
 		filters:             make(map[int]Filter),
+		filterNames:         make(map[int]string),
 		rowHeaders:          newRootHeaders(nil),
 		columnHeaders:       newRootHeaders(nil),
 		// TODO populate this variable when several values are requested and use it for Generate
 		valueHeaders: nil,
 		rowSeries:    make([]*series[string], 0),
 		columnSeries: make([]*series[string], 0),
-		valueSeries:  make([]*series[float64], 0),
-		newVSeries:   newVSeries,
-		newCell:      newPivotCell,
-		cellValue:    toFloat,
-		err:          err,
+		valueSeries:  make([]*series[T], 0),
+		pageIndex:    -1,
+		newVSeries:   newVSeries[T],
+		newCell:      newPivotCell[T],
+		cellValue:    converterOf[T](),
+		arithmetic:   arithmeticOf[T](),
+		display:      &display{},
+		emptyPolicy:  EmptyAsZero,
+		blankLabel:   BLANK_LABEL,
 	}
 }
 
-func (t *Table[T]) updateCell(rowLabel string, columnLabel string, record []interface{}, verbose bool) error {
-	rr, ok := t.cells[rowLabel]
-	if !ok {
-		rr = make(map[string]cell[T])
-		t.cells[rowLabel] = rr
+// spawn creates an empty table sharing t definitions for given (already filtered) records
+func (t *Table[T]) spawn(records [][]interface{}) *Table[T] {
+	var data [][]interface{}
+	if t.dataHeaders {
+		data = append(data, t.data[0])
 	}
-	rc, ok := rr[columnLabel]
-	if !ok {
-		displays := make([]ValueFormat, len(t.valueSeries))
-		for i, s := range t.valueSeries {
-			displays[i] = ValueFormat(s.format)
-		}
-		rc = t.newCell(displays)
-		rr[columnLabel] = rc
+	data = append(data, records...)
+	return &Table[T]{
+		data:                data,
+		dataHeaders:         t.dataHeaders,
+		registeredRCIndexes: t.registeredRCIndexes,
+		registeredVIndexes:  t.registeredVIndexes,
+		cells:               make(map[cellKey]cell[T]),
+		filters:             make(map[int]Filter),
+		rowHeaders:          newRootHeaders(t.rowHeaders.defaultSort),
+		columnHeaders:       newRootHeaders(t.columnHeaders.defaultSort),
+		rowSeries:           t.rowSeries,
+		columnSeries:        t.columnSeries,
+		valueSeries:         t.valueSeries,
+		pageIndex:           -1,
+		newVSeries:          t.newVSeries,
+		newCell:             t.newCell,
+		cellValue:           t.cellValue,
+		arithmetic:          t.arithmetic,
+		display:             t.display,
+		emptyPolicy:         t.emptyPolicy,
+		blankLabel:          t.blankLabel,
+		drillDown:           t.drillDown,
 	}
-	for k := range t.registeredVIndexes {
-		value, err := t.cellValue(record[k.index])
-		if err != nil && err != ErrEmptyValue {
-			return fmt.Errorf("while updating cell[%q,%q] with record %v: %w", rowLabel, columnLabel, record, err)
-		} else if err == ErrEmptyValue && verbose {
-			fmt.Printf("WARNING: found record %v with empty value while updating cell[%q,%q]\n", record, rowLabel, columnLabel)
-		}
-		rc.Record(k, value)
+}
+
+// newLayout sorts data references and resolves their positions for each value series
+func (t *Table[T]) newLayout() *layout[T] {
+	l := &layout[T]{
+		formats:    make([]string, len(t.valueSeries)),
+		dataRefs:   sortedDataRefs(t.registeredVIndexes),
+		display:    t.display,
+		arithmetic: t.arithmetic,
 	}
-	for is, serie := range t.valueSeries {
-		err := rc.Set(is, serie.compute, serie.dataRefs)
-		if err != nil {
-			return fmt.Errorf("while updating cell[%q,%q] with record %v: %w", rowLabel, columnLabel, record, err)
-		} else if err == ErrEmptyValue && verbose {
-			fmt.Printf("WARNING: found record %v with empty value while updating cell[%q,%q]\n", record, rowLabel, columnLabel)
+	positions := make(map[DataRef]int, len(l.dataRefs))
+	for i, k := range l.dataRefs {
+		positions[k] = i
+	}
+	for i, serie := range t.valueSeries {
+		l.formats[i] = serie.format
+		serie.refs = make([]int, len(serie.dataRefs))
+		for j, k := range serie.dataRefs {
+			serie.refs[j] = positions[k]
 		}
 	}
-	return nil
+	return l
+}
+
+// parsedRecord holds the values of a record converted for each data reference of the table layout
+type parsedRecord[T valueType] struct {
+	index   int
+	record  []interface{}
+	values  []T
+	texts   []string
+	skipped []bool
+	empties []int
+}
+
+func (t *Table[T]) newParsedRecord() *parsedRecord[T] {
+	return &parsedRecord[T]{
+		values:  make([]T, len(t.layout.dataRefs)),
+		texts:   make([]string, len(t.layout.dataRefs)),
+		skipped: make([]bool, len(t.layout.dataRefs)),
+	}
 }
 
-func (t *Table[T]) updateCrossCells(rowLabel string, columnLabel string, record []interface{}) error {
-	sumColumnLabel := columnLabel
-	for i := 0; i < len(t.columnSeries)+1; i++ {
-		sumRowLabel := rowLabel
-		for j := 0; j < len(t.rowSeries)+1; j++ {
-			if i != 0 || j != 0 {
-				err := t.updateCell(sumRowLabel, sumColumnLabel, record, false)
-				if err != nil {
-					return err
+// parseRecord fills parsed with record values, parsed being reused from one record to the other
+func (t *Table[T]) parseRecord(index int, record []interface{}, parsed *parsedRecord[T]) *RecordError {
+	parsed.index = index
+	parsed.record = record
+	parsed.empties = parsed.empties[:0]
+	for i, k := range t.layout.dataRefs {
+		parsed.skipped[i] = false
+		policy := k.empty
+		if policy == DefaultEmpty {
+			policy = t.emptyPolicy
+		}
+		var err error
+		column := k.index
+		if k.operation == text {
+			if IsEmpty(record[k.index]) {
+				err = ErrEmptyValue
+			} else {
+				parsed.texts[i] = toString(record[k.index])
+			}
+		} else {
+			parsed.values[i], err = t.cellValue(record[k.index], t.display.locale)
+			if (k.operation == weightedSum || k.operation == weights) && (err == nil || err == ErrEmptyValue) {
+				weight, weightErr := t.cellValue(record[k.weight], t.display.locale)
+				if weightErr == ErrEmptyValue && err == nil {
+					err, column = weightErr, k.weight
+				} else if weightErr != nil && weightErr != ErrEmptyValue {
+					return newRecordError(index, k.weight, record, weightErr)
 				}
+				if k.operation == weights {
+					parsed.values[i] = weight
+				} else {
+					if t.arithmetic.mulOverflows != nil && t.arithmetic.mulOverflows(parsed.values[i], weight) {
+						return newRecordError(index, k.index, record, ErrIntegerOverflow)
+					}
+					parsed.values[i] = t.arithmetic.mul(parsed.values[i], weight)
+					if t.arithmetic.check != nil {
+						if err := t.arithmetic.check(parsed.values[i]); err != nil {
+							return newRecordError(index, k.index, record, err)
+						}
+					}
+				}
+			}
+		}
+		if err == ErrEmptyValue {
+			parsed.empties = append(parsed.empties, column)
+			if policy == FailOnEmpty {
+				return newRecordError(index, column, record, err)
 			}
-			sumRowLabel = parentHeaderLabel(sumRowLabel)
+			parsed.skipped[i] = policy == SkipEmpty || k.operation == text
+		} else if err != nil {
+			return newRecordError(index, column, record, err)
 		}
-		sumColumnLabel = parentHeaderLabel(sumColumnLabel)
 	}
 	return nil
 }
 
+// updateCell only records values, cells are computed once all records are aggregated, see finalize
+func (t *Table[T]) updateCell(row *headers, column *headers, parsed *parsedRecord[T]) {
+	key := cellKey{row: row.id, column: column.id}
+	rc, ok := t.cells[key]
+	if !ok {
+		rc = t.newCell(t.layout)
+		t.cells[key] = rc
+	}
+	if t.dirty != nil {
+		t.dirty[key] = true
+	}
+	rc.Tally(1)
+	for i, k := range t.layout.dataRefs {
+		if parsed.skipped[i] {
+			continue
+		}
+		if k.operation == text {
+			rc.RecordText(i, parsed.texts[i])
+		} else {
+			rc.Record(i, parsed.values[i])
+		}
+	}
+}
+
+func (t *Table[T]) updateCrossCells(row *headers, column *headers, parsed *parsedRecord[T]) {
+	for c := column; c != nil; c = c.parent {
+		for r := row; r != nil; r = r.parent {
+			if r != row || c != column {
+				t.updateCell(r, c, parsed)
+			}
+		}
+	}
+}
+
+// fail returns a handler recording errors when lenient mode allows it, otherwise returning them
+func (t *Table[T]) fail(logger *slog.Logger) func(*RecordError) error {
+	return func(err *RecordError) error {
+		if len(t.recordErrors) < t.maxRecordErrors {
+			logger.Warn("skipped bad record", "record", err.Row, "column", err.Column, "error", err.Err)
+			t.recordErrors = append(t.recordErrors, err)
+			return nil
+		}
+		return err
+	}
+}
+
 func (t *Table[T]) registerRow(indexes []int, filter Filter, compute Compute[string], sort Sort) error {
 	if len(indexes) == 0 {
 		return fmt.Errorf("invalid row definition, no indexes given")
@@ -189,58 +368,192 @@ func (t *Table[T]) registerValue(name string, dataRefs []DataRef, compute Comput
 	return nil
 }
 
-func (t *Table[T]) Generate(verbose bool) error {
-	if t.err != nil {
-		return t.err
+type GenerateOption func(*generateConfig)
+
+type generateConfig struct {
+	logger  *slog.Logger
+	workers int
+}
+
+// WithLogger reports warnings like empty values or skipped records to logger, nothing is logged by default
+func WithLogger(logger *slog.Logger) GenerateOption {
+	return func(c *generateConfig) {
+		c.logger = logger
+	}
+}
+
+// WithWorkers aggregates records with n goroutines, each one working on a contiguous share of records
+// merged in order afterwards. Compute functions of rows and columns must then be safe for concurrent use.
+func WithWorkers(n int) GenerateOption {
+	return func(c *generateConfig) {
+		c.workers = n
 	}
-	if len(t.rowSeries) == 0 {
-		return fmt.Errorf("no rows defined")
+}
+
+func newGenerateConfig(options []GenerateOption) *generateConfig {
+	config := &generateConfig{logger: slog.New(discardHandler{}), workers: 1}
+	for _, option := range options {
+		option(config)
 	}
-	if len(t.columnSeries) == 0 {
-		return fmt.Errorf("no columns defined")
+	return config
+}
+
+// Generate aggregates input data, a table without rows or columns has a single Total header on that axis
+func (t *Table[T]) Generate(options ...GenerateOption) error {
+	config := newGenerateConfig(options)
+	t.logger = config.logger
+	fail := t.fail(config.logger)
+	if t.err != nil {
+		return t.err
 	}
 	if len(t.valueSeries) == 0 {
 		return fmt.Errorf("no values defined")
 	}
-	var headerSeries []*series[string]
+	t.recordErrors = nil
+	t.layout = t.newLayout()
+	if t.drillDown {
+		t.drill = make(map[cellKey][]int)
+	}
+	t.stats = newStats(len(t.recordFilters))
+	start := time.Now()
 	var headerLabels []interface{}
 	if t.dataHeaders {
 		headerLabels = t.data[0]
 	}
-	headerSeries = append(headerSeries, t.rowSeries...)
-	headerSeries = append(headerSeries, t.columnSeries...)
+	headerSeries := t.headerSeries()
 	for _, serie := range headerSeries {
 		serie.NameFromHeaders(headerLabels)
 	}
-	filteredData, err := filter(t.filters, headerSeries, t.data, t.dataHeaders)
+	t.stats.Records = len(t.data)
+	if t.dataHeaders {
+		t.stats.Records--
+	}
+	if t.infer {
+		t.schema = t.inferSchema()
+	}
+	data, err := t.parseSchema(t.data, 0, fail)
+	if err != nil {
+		return err
+	}
+	filteredIndexes, err := filter(t.filters, t.recordFilters, headerSeries, data, 0, t.dataHeaders, fail, t.stats)
 	if err != nil {
 		return err
 	}
+	t.stats.FilterDuration = time.Since(start)
 	for _, serie := range t.valueSeries {
 		serie.NameFromHeaders(headerLabels)
 	}
-	for _, record := range filteredData {
-		var rowLabel string
-		var columnLabel string
-		rowLabel, err = walk(t.rowHeaders, t.rowSeries, record)
+	var generatedRecords [][]interface{}
+	if config.workers > 1 && len(filteredIndexes) > 1 {
+		generatedRecords, err = t.aggregateParallel(data, filteredIndexes, fail, config)
+	} else {
+		generatedRecords, err = t.aggregate(data, 0, filteredIndexes, fail, config.logger)
+		if err == nil {
+			start = time.Now()
+			err = t.finalize()
+			t.stats.AggregateDuration += time.Since(start)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	t.stats.Generated = len(generatedRecords)
+	t.updateStats()
+	if t.pageIndex >= 0 {
+		err = t.generatePages(generatedRecords, options)
 		if err != nil {
 			return err
 		}
-		if len(rowLabel) == 0 {
-			return fmt.Errorf("empty row labels are not supported")
+	}
+	return nil
+}
+
+func (t *Table[T]) headerSeries() []*series[string] {
+	var result []*series[string]
+	result = append(result, t.rowSeries...)
+	return append(result, t.columnSeries...)
+}
+
+// parseSchema converts records, the first one being at offset in input data, with the table schema if any
+func (t *Table[T]) parseSchema(records [][]interface{}, offset int, fail func(*RecordError) error) ([][]interface{}, error) {
+	if t.schema == nil {
+		return records, nil
+	}
+	data := make([][]interface{}, len(records))
+	for i, record := range records {
+		if i+offset == 0 && t.dataHeaders {
+			data[i] = record
+			continue
 		}
-		columnLabel, err = walk(t.columnHeaders, t.columnSeries, record)
+		parsed, column, err := t.schema.parseRecord(record, t.display.locale)
 		if err != nil {
-			return err
+			err = fail(newRecordError(i+offset, column, record, err))
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
-		if len(columnLabel) == 0 {
-			return fmt.Errorf("empty column labels are not supported")
+		data[i] = parsed
+	}
+	return data, nil
+}
+
+func (t *Table[T]) updateStats() {
+	t.stats.Skipped = len(t.recordErrors)
+	t.stats.RowLabels = levelSizes(t.rowHeaders, len(t.rowSeries))
+	t.stats.ColumnLabels = levelSizes(t.columnHeaders, len(t.columnSeries))
+	t.stats.Cells = len(t.cells)
+}
+
+// aggregate walks headers and updates cells for records at given indexes, it returns the records actually aggregated.
+// Indexes are positions in input data, data holding input records from offset.
+func (t *Table[T]) aggregate(data [][]interface{}, offset int, indexes []int, fail func(*RecordError) error, logger *slog.Logger) ([][]interface{}, error) {
+	var generatedRecords [][]interface{}
+	parsed := t.newParsedRecord()
+	for _, i := range indexes {
+		record := data[i-offset]
+		start := time.Now()
+		recordErr := t.parseRecord(i, record, parsed)
+		walkStart := time.Now()
+		t.stats.AggregateDuration += walkStart.Sub(start)
+		if recordErr != nil {
+			err := fail(recordErr)
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
-		err = t.updateCell(rowLabel, columnLabel, record, verbose)
+		row, err := walk(t.rowHeaders, t.rowSeries, record, t.blankLabel)
 		if err != nil {
-			return err
+			return nil, newRecordError(i, -1, record, err)
+		}
+		column, err := walk(t.columnHeaders, t.columnSeries, record, t.blankLabel)
+		if err != nil {
+			return nil, newRecordError(i, -1, record, err)
+		}
+		if len(parsed.empties) > 0 {
+			logger.Warn("found record with empty value", "record", i, "row", row.path(), "column", column.path())
+		}
+		for j, index := range parsed.empties {
+			if j == 0 || index != parsed.empties[j-1] {
+				t.stats.EmptyValues[index]++
+			}
 		}
-		err = t.updateCrossCells(rowLabel, columnLabel, record)
+		aggregateStart := time.Now()
+		t.stats.WalkDuration += aggregateStart.Sub(walkStart)
+		t.updateCell(row, column, parsed)
+		t.keepRecord(cellKey{row: row.id, column: column.id}, i)
+		t.updateCrossCells(row, column, parsed)
+		t.stats.AggregateDuration += time.Since(aggregateStart)
+		generatedRecords = append(generatedRecords, record)
+	}
+	return generatedRecords, nil
+}
+
+// finalize computes the values of all cells from their recorded state
+func (t *Table[T]) finalize() error {
+	for key, rc := range t.cells {
+		err := t.finalizeCell(key, rc)
 		if err != nil {
 			return err
 		}
@@ -248,34 +561,147 @@ func (t *Table[T]) Generate(verbose bool) error {
 	return nil
 }
 
+func (t *Table[T]) finalizeCell(key cellKey, rc cell[T]) error {
+	for is, serie := range t.valueSeries {
+		if serie.aggregate != nil {
+			rc.SetText(is, serie.aggregate, serie.refs[0])
+			continue
+		}
+		err := rc.Set(is, serie.compute, serie.refs)
+		if err != nil {
+			return fmt.Errorf("while computing cell[%q,%q]: %w", t.rowHeaders.tree.nodes[key.row].path(), t.columnHeaders.tree.nodes[key.column].path(), err)
+		}
+	}
+	return nil
+}
+
+// Stats returns statistics of the last Generate, nil before
+func (t *Table[T]) Stats() *Stats {
+	return t.stats
+}
+
+// RecordErrors returns the bad records skipped by the last Generate in lenient mode
+func (t *Table[T]) RecordErrors() []*RecordError {
+	return t.recordErrors
+}
+
+func (t *Table[T]) generatePages(records [][]interface{}, options []GenerateOption) error {
+	pageRecords := make(map[string][][]interface{})
+	for _, record := range records {
+		label := t.pageLabel(record)
+		pageRecords[label] = append(pageRecords[label], record)
+	}
+	t.pages = make(map[string]*Table[T])
+	for label, recs := range pageRecords {
+		page := t.spawn(recs)
+		err := page.Generate(options...)
+		if err != nil {
+			return fmt.Errorf("while generating page %q: %w", label, err)
+		}
+		t.pages[label] = page
+	}
+	return nil
+}
+
+func (t *Table[T]) pageLabel(record []interface{}) string {
+	if IsEmpty(record[t.pageIndex]) {
+		return t.blankLabel
+	}
+	return toString(record[t.pageIndex])
+}
+
+// Pages returns generated pivots per distinct value of the page column, nil if no page is defined
+func (t *Table[T]) Pages() map[string]*Table[T] {
+	return t.pages
+}
+
+// PageLabels returns the distinct values of the page column in alphabetical order
+func (t *Table[T]) PageLabels() []string {
+	keys := make([]Header, 0, len(t.pages))
+	for k := range t.pages {
+		keys = append(keys, Header(k))
+	}
+	keys = AlphaSort(keys)
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = string(k)
+	}
+	return labels
+}
+
+// ToCSVPages renders each page as CSV, keyed by page value
+func (t *Table[T]) ToCSVPages(options ...RenderOption) map[string]string {
+	result := make(map[string]string, len(t.pages))
+	for label, page := range t.pages {
+		result[label] = page.ToCSV(options...)
+	}
+	return result
+}
+
+// WriteCSVPages writes each page as a separate CSV file in dir, named after the page value. It fails without
+// writing anything when two pages would share a file name.
+func (t *Table[T]) WriteCSVPages(dir string, options ...RenderOption) error {
+	if t.pages == nil {
+		return fmt.Errorf("no pages generated")
+	}
+	labels := make(map[string]string, len(t.pages))
+	for _, label := range t.PageLabels() {
+		// lower case names also collide on case insensitive file systems
+		name := strings.ToLower(pageFileName(label))
+		if other, ok := labels[name]; ok {
+			return fmt.Errorf("pages %q and %q have the same file name", other, label)
+		}
+		labels[name] = label
+	}
+	for _, label := range t.PageLabels() {
+		name := filepath.Join(dir, pageFileName(label)+".csv")
+		err := os.WriteFile(name, []byte(t.pages[label].ToCSV(options...)), 0644)
+		if err != nil {
+			return fmt.Errorf("while writing page %q: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// RowPaths returns generated row headers in rendering order, the total being the last empty path
+func (t *Table[T]) RowPaths() []HeaderPath {
+	return t.rowHeaders.paths(true, true)
+}
+
+// ColumnPaths returns generated column headers in rendering order, the total being the last empty path
+func (t *Table[T]) ColumnPaths() []HeaderPath {
+	return t.columnHeaders.paths(true, true)
+}
+
 // ToCSV
 // TODO manage multi-values through virtual column
-func (t *Table[T]) ToCSV() string {
-	columnLabels := t.columnHeaders.labels(true, true)
-	rowLabels := t.rowHeaders.labels(true, true)
+func (t *Table[T]) ToCSV(options ...RenderOption) string {
+	config := newRenderConfig(options)
+	columns := config.columns.visible(t.columnHeaders)
+	rows := config.rows.visible(t.rowHeaders)
 	var sb strings.Builder
-	for _, columnLabel := range columnLabels {
-		if columnLabel == "" {
+	for _, column := range columns {
+		if column.parent == nil {
 			_, _ = fmt.Fprint(&sb, ";Total")
 		} else {
-			_, _ = fmt.Fprint(&sb, ";"+columnLabel)
+			_, _ = fmt.Fprint(&sb, ";"+column.path().Join(config.separator))
 		}
 	}
 	_, _ = fmt.Fprintln(&sb)
-	for _, rowLabel := range rowLabels {
-		if rowLabel == "" {
+	for _, row := range rows {
+		if row.parent == nil {
 			_, _ = fmt.Fprint(&sb, "Total;")
 		} else {
-			_, _ = fmt.Fprint(&sb, rowLabel+";")
+			_, _ = fmt.Fprint(&sb, row.path().Join(config.separator)+";")
 		}
-		for i, columnLabel := range columnLabels {
-			v, ok := t.cells[rowLabel][columnLabel]
+		for i, column := range columns {
+			v, ok := t.cells[cellKey{row: row.id, column: column.id}]
 			if ok {
-				_, _ = fmt.Fprintf(&sb, v.String())
+				_, _ = fmt.Fprint(&sb, v.String())
 			} else {
-				_, _ = fmt.Fprintf(&sb, "")
+				_, _ = fmt.Fprint(&sb, t.display.absent)
 			}
-			if i < len(columnLabels)-1 {
+			if i < len(columns)-1 {
 				_, _ = fmt.Fprintf(&sb, ";")
 			}
 		}
@@ -286,6 +712,160 @@ func (t *Table[T]) ToCSV() string {
 
 func (t *Table[T]) Filter(index int, filter Filter) *Table[T] {
 	t.filters[index] = filter
+	delete(t.filterNames, index)
+	return t
+}
+
+// FilterRecords keeps only records matching filter, useful when filtering depends on several columns
+func (t *Table[T]) FilterRecords(filter RecordFilter) *Table[T] {
+	t.recordFilters = append(t.recordFilters, filter)
+	t.recordFilterNames = append(t.recordFilterNames, "")
+	return t
+}
+
+// Functions sets the registry looking up functions named by FilterBy, NamedRow and the like. Only tables whose
+// functions are all named can be snapshotted or merged.
+func (t *Table[T]) Functions(registry *Registry) *Table[T] {
+	t.registry = registry
+	return t
+}
+
+// lookupFunction returns the function named name in the table registry, nil for an empty name
+func lookupFunction[F any, T valueType](t *Table[T], name string) F {
+	var result F
+	var err error
+	if len(name) == 0 {
+		return result
+	}
+	if t.registry == nil {
+		err = fmt.Errorf("invalid function %q, no registry given", name)
+	} else if t.registry.err != nil {
+		err = t.registry.err
+	} else {
+		result, err = lookup[F](t.registry, name)
+	}
+	if t.err == nil {
+		t.err = err
+	}
+	return result
+}
+
+// FilterBy is Filter with a function named in the table registry
+func (t *Table[T]) FilterBy(index int, filter string) *Table[T] {
+	t.Filter(index, lookupFunction[Filter](t, filter))
+	t.filterNames[index] = filter
+	return t
+}
+
+// FilterRecordsBy is FilterRecords with a function named in the table registry
+func (t *Table[T]) FilterRecordsBy(filter string) *Table[T] {
+	t.FilterRecords(lookupFunction[RecordFilter](t, filter))
+	t.recordFilterNames[len(t.recordFilterNames)-1] = filter
+	return t
+}
+
+// Schema converts input elements to declared column types before filtering and aggregation
+func (t *Table[T]) Schema(schema Schema) *Table[T] {
+	if len(t.data) > 0 && len(schema) != len(t.data[0]) && t.err == nil {
+		t.err = fmt.Errorf("invalid schema, %d columns declared for %d input columns", len(schema), len(t.data[0]))
+	}
+	t.schema = schema
+	return t
+}
+
+// Locale sets how numbers are parsed from input strings and rendered in output. Filters see input elements as they
+// are, declare numeric columns in a Schema so that numeric filters like Between get values parsed with locale.
+func (t *Table[T]) Locale(locale *Locale) *Table[T] {
+	if locale != nil && t.err == nil {
+		if decimal, thousands := locale.separators(); decimal == thousands {
+			t.err = fmt.Errorf("invalid locale, %q is both the decimal and thousands separator", decimal)
+		}
+	}
+	t.display.locale = locale
+	return t
+}
+
+// Lenient skips up to maxErrors bad records instead of failing, they are reported by RecordErrors
+func (t *Table[T]) Lenient(maxErrors int) *Table[T] {
+	t.maxRecordErrors = maxErrors
+	return t
+}
+
+// EmptyValues sets how empty input values are handled by data references not overriding it with OnEmpty
+func (t *Table[T]) EmptyValues(policy EmptyPolicy) *Table[T] {
+	if policy == DefaultEmpty {
+		policy = EmptyAsZero
+	}
+	t.emptyPolicy = policy
+	return t
+}
+
+// AbsentAs sets the text rendered for cells or values without data, empty by default
+func (t *Table[T]) AbsentAs(text string) *Table[T] {
+	t.display.absent = text
+	return t
+}
+
+// BlankAs sets the label of headers grouping empty or nil values, BLANK_LABEL by default.
+// Use the NotBlank filter on a row or column to drop such records instead.
+func (t *Table[T]) BlankAs(label string) *Table[T] {
+	if len(label) == 0 && t.err == nil {
+		t.err = fmt.Errorf("invalid blank label, empty labels are not supported")
+	}
+	t.blankLabel = label
+	return t
+}
+
+// InvalidAs sets the text rendered for NaN or infinite computed values instead of their fmt representation
+func (t *Table[T]) InvalidAs(text string) *Table[T] {
+	t.display.invalid = &text
+	return t
+}
+
+// InferSchema sets, when generating, a schema guessed from the first sampleSize records for numeric value columns.
+// Other columns stay strings, so that row, column and page labels or text values are kept as written.
+func (t *Table[T]) InferSchema(sampleSize int) *Table[T] {
+	t.inferSize = sampleSize
+	t.infer = true
+	return t
+}
+
+// inferSchema guesses the types of columns aggregated as numbers
+func (t *Table[T]) inferSchema() Schema {
+	numeric := make(map[int]bool)
+	for _, serie := range t.valueSeries {
+		for _, k := range serie.dataRefs {
+			if k.operation != text {
+				numeric[k.index] = true
+			}
+			if k.operation == weightedSum || k.operation == weights {
+				numeric[k.weight] = true
+			}
+		}
+	}
+	schema := InferSchema(t.data, t.dataHeaders, t.inferSize)
+	for i := range schema {
+		if !numeric[i] || t.registeredRCIndexes[i] {
+			schema[i] = ColumnSchema{Type: StringColumn}
+		}
+	}
+	return schema
+}
+
+// Page generates, besides the whole pivot, one pivot per distinct value of the column at index
+func (t *Table[T]) Page(index int) *Table[T] {
+	var err error
+	if _, ok := t.registeredRCIndexes[index]; ok {
+		err = fmt.Errorf("invalid page definition, index already used")
+	} else if t.pageIndex >= 0 {
+		err = fmt.Errorf("invalid page definition, page already defined")
+	} else {
+		t.registeredRCIndexes[index] = true
+		t.pageIndex = index
+	}
+	if t.err == nil {
+		t.err = err
+	}
 	return t
 }
 
@@ -301,6 +881,18 @@ func (t *Table[T]) ComputedRow(indexes []int, filter Filter, compute Compute[str
 	return t
 }
 
+// NamedRow is ComputedRow with functions named in the table registry, empty names standing for nil functions
+func (t *Table[T]) NamedRow(indexes []int, filter, compute, sort string) *Table[T] {
+	err := t.registerRow(indexes, lookupFunction[Filter](t, filter), lookupFunction[Compute[string]](t, compute), lookupFunction[Sort](t, sort))
+	if err == nil {
+		t.rowSeries[len(t.rowSeries)-1].names = functionNames{filter: filter, compute: compute, sort: sort}
+	}
+	if t.err == nil {
+		t.err = err
+	}
+	return t
+}
+
 func (t *Table[T]) Column(index int) *Table[T] {
 	return t.ComputedColumn([]int{index}, nil, nil, nil)
 }
@@ -313,6 +905,18 @@ func (t *Table[T]) ComputedColumn(indexes []int, filter Filter, compute Compute[
 	return t
 }
 
+// NamedColumn is ComputedColumn with functions named in the table registry, empty names standing for nil functions
+func (t *Table[T]) NamedColumn(indexes []int, filter, compute, sort string) *Table[T] {
+	err := t.registerColumn(indexes, lookupFunction[Filter](t, filter), lookupFunction[Compute[string]](t, compute), lookupFunction[Sort](t, sort))
+	if err == nil {
+		t.columnSeries[len(t.columnSeries)-1].names = functionNames{filter: filter, compute: compute, sort: sort}
+	}
+	if t.err == nil {
+		t.err = err
+	}
+	return t
+}
+
 func (t *Table[T]) Values(index int, operation Operation, format string) *Table[T] {
 	dataRef := DataRef{index: index, operation: operation}
 	err := t.registerValue("", []DataRef{dataRef}, nil, format)
@@ -322,6 +926,28 @@ func (t *Table[T]) Values(index int, operation Operation, format string) *Table[
 	return t
 }
 
+// TextValues aggregates the column at index as text, rendered alongside numeric values
+func (t *Table[T]) TextValues(index int, aggregate TextAggregate) *Table[T] {
+	dataRef := DataRef{index: index, operation: text}
+	err := t.registerValue("", []DataRef{dataRef}, nil, "%s")
+	if err == nil {
+		t.valueSeries[len(t.valueSeries)-1].aggregate = aggregate
+	}
+	if t.err == nil {
+		t.err = err
+	}
+	return t
+}
+
+// NamedTextValues is TextValues with an aggregate named in the table registry
+func (t *Table[T]) NamedTextValues(index int, aggregate string) *Table[T] {
+	t.TextValues(index, lookupFunction[TextAggregate](t, aggregate))
+	if t.err == nil {
+		t.valueSeries[len(t.valueSeries)-1].names.aggregate = aggregate
+	}
+	return t
+}
+
 func (t *Table[T]) ComputedValues(name string, dataRefs []DataRef, compute Compute[T], format string) *Table[T] {
 	err := t.registerValue(name, dataRefs, compute, format)
 	if t.err == nil {
@@ -329,3 +955,15 @@ func (t *Table[T]) ComputedValues(name string, dataRefs []DataRef, compute Compu
 	}
 	return t
 }
+
+// NamedValues is ComputedValues with a compute named in the table registry
+func (t *Table[T]) NamedValues(name string, dataRefs []DataRef, compute string, format string) *Table[T] {
+	err := t.registerValue(name, dataRefs, lookupFunction[Compute[T]](t, compute), format)
+	if err == nil {
+		t.valueSeries[len(t.valueSeries)-1].names.compute = compute
+	}
+	if t.err == nil {
+		t.err = err
+	}
+	return t
+}
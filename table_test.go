@@ -1,23 +1,17 @@
 package pivot
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestTable(t *testing.T) {
-	if parentHeaderLabel("") != "" {
-		t.Fatalf("parentHeaderLabel(\"\")=%s!=\"\"", parentHeaderLabel(""))
-	}
-	if parentHeaderLabel("A1") != "" {
-		t.Fatalf("parentHeaderLabel(\"A1\")=%s!=\"\"", parentHeaderLabel("A1"))
-	}
-	if parentHeaderLabel("A1/B1") != "A1" {
-		t.Fatalf("parentHeaderLabel(\"A1/B1\")=%s!=\"A1\"", parentHeaderLabel("A1/B1"))
-	}
-	if parentHeaderLabel("A1/B1/C1") != "A1/B1" {
-		t.Fatalf("parentHeaderLabel(\"A1/B1/C1\")=%s!=\"A1/B1\"", parentHeaderLabel("A1/B1/C1"))
-	}
 	rawData := [][]interface{}{
 		{"A1", "B1", "C1", "D1", 4},
 		{"A1", "B2", "C1", "D1", 2},
@@ -43,7 +37,7 @@ func TestTable(t *testing.T) {
 		Row(2).
 		Column(3).
 		Values(4, Sum, Digits(0))
-	err := table.Generate(false)
+	err := table.Generate()
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -54,13 +48,107 @@ func TestTable(t *testing.T) {
 		Column(2).
 		Column(3).
 		Values(4, Sum, Digits(0))
-	err = table.Generate(false)
+	err = table.Generate()
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
 	fmt.Println(table.ToCSV())
 }
 
+func TestHeaderPaths(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A | 1", "B1", "D1", 4},
+		{"A | 1", "B2", "D1", 2},
+		{"A", "1 | B1", "D1", 3},
+	}
+	table := NewTable(rawData, false).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedRow([]int{1}, nil, nil, AlphaSort).
+		Column(2).
+		Values(3, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";D1;Total\nA;3;3\nA/1 | B1;3;3\nA | 1;6;6\nA | 1/B1;4;4\nA | 1/B2;2;2\nTotal;9;9\n"
+	result := table.ToCSV(WithSeparator("/"))
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	paths := table.RowPaths()
+	if len(paths) != 6 || len(paths[1]) != 2 || paths[1][1] != "1 | B1" || len(paths[5]) != 0 {
+		t.Fatalf("table.RowPaths()=%v", paths)
+	}
+}
+
+func TestBlankLabels(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", true, 4},
+		{"", false, 2},
+		{nil, true, 3},
+		{"A2", nil, 1},
+	}
+	table := NewTable(rawData, false).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";(blank);false;true;Total\n(blank);;2;3;5\nA1;;;4;4\nA2;1;;;1\nTotal;1;2;7;10\n"
+	result := table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	table = NewTable(rawData, false).
+		ComputedRow([]int{0}, NotBlank, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0)).
+		BlankAs("-")
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";-;true;Total\nA1;;4;4\nA2;1;;1\nTotal;1;4;5\n"
+	result = table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+}
+
+func TestSingleAxis(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", 4},
+		{"A2", "B1", 2},
+		{"A1", "B2", 3},
+	}
+	table := NewTable(rawData, false).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";Total\nA1;7\nA2;2\nTotal;9\n"
+	result := table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+	table = NewTable(rawData, false).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0))
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected = ";B1;B2;Total\nTotal;6;3;9\n"
+	result = table.ToCSV()
+	if result != expected {
+		t.Fatalf("table.ToCSV()=%s!=%s", result, expected)
+	}
+}
+
 func TestComputeSet(t *testing.T) {
 	rawData := [][]interface{}{
 		{"A", "B", "V1", "V2", "V3", "V4"},
@@ -79,18 +167,262 @@ func TestComputeSet(t *testing.T) {
 		T  2     1,4   4     1     1     4     3     1,25  8
 
 	*/
-	compute := func(elements []RawValue) (float64, error) {
-		return (elements[0].(float64)) / (elements[1].(float64)), nil
-	}
 	table := NewTable(rawData, true).
 		Row(0).
 		Column(1).
 		Values(3, Count, Digits(0)).
-		ComputedValues("V4/V2", DataRefs([]int{5, 3}, Sum), compute, Digits(2)).
+		ComputedValues("V4/V2", DataRefs([]int{5, 3}, Sum), Ratio[float64](ZeroAsError), Digits(2)).
 		Values(4, Sum, Digits(0))
-	err := table.Generate(false)
+	err := table.Generate()
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
 	fmt.Println(table.ToCSV())
 }
+
+func TestPages(t *testing.T) {
+	rawData := [][]interface{}{
+		{"Country", "A", "B", "V"},
+		{"FR", "A1", "B1", 4},
+		{"FR", "A1", "B2", 2},
+		{"US", "A2", "B1", 3},
+		{"US", "A1", "B1", 1},
+	}
+	table := NewTable(rawData, true).
+		Page(0).
+		ComputedRow([]int{1}, nil, nil, AlphaSort).
+		ComputedColumn([]int{2}, nil, nil, AlphaSort).
+		Values(3, Sum, Digits(0))
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	labels := table.PageLabels()
+	if len(labels) != 2 || labels[0] != "FR" || labels[1] != "US" {
+		t.Fatalf("table.PageLabels()=%v!=[FR US]", labels)
+	}
+	expected := ";B1;B2;Total\nA1;4;2;6\nTotal;4;2;6\n"
+	if table.ToCSVPages()["FR"] != expected {
+		t.Fatalf("table.ToCSVPages()[FR]=%q!=%q", table.ToCSVPages()["FR"], expected)
+	}
+	expected = ";B1;Total\nA1;1;1\nA2;3;3\nTotal;4;4\n"
+	if table.ToCSVPages()["US"] != expected {
+		t.Fatalf("table.ToCSVPages()[US]=%q!=%q", table.ToCSVPages()["US"], expected)
+	}
+	dir := t.TempDir()
+	err = table.WriteCSVPages(dir)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "US.csv"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if string(content) != expected {
+		t.Fatalf("US.csv=%q!=%q", string(content), expected)
+	}
+	rawData = append(rawData, []interface{}{"a/b", "A1", "B1", 1}, []interface{}{"a_b", "A1", "B1", 1})
+	table = NewTable(rawData, true).
+		Page(0).
+		Row(1).
+		Column(2).
+		Values(3, Sum, Digits(0))
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = table.WriteCSVPages(t.TempDir())
+	if err == nil {
+		t.Fatalf("expected error with pages sharing a file name")
+	}
+}
+
+func TestIntegerTables(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", "9007199254740993"},
+		{"A1", "B1", 2},
+	}
+	table := NewTableOf[int64](rawData, false).
+		Row(0).
+		Column(1).
+		ComputedValues("S", DataRefs([]int{2}, Sum), SumOf[int64], "%d")
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;Total\nA1;9007199254740995;9007199254740995\nTotal;9007199254740995;9007199254740995\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	rawData = append(rawData, []interface{}{"A2", "B1", "-1"})
+	unsignedTable := NewTableOf[uint64](rawData, false).
+		Row(0).
+		Column(1).
+		Values(2, Sum, "%d")
+	err = unsignedTable.Generate()
+	if err == nil {
+		t.Fatalf("expected error for negative unsigned value")
+	}
+	overflows := [][]interface{}{
+		{"A1", "9223372036854775807", "2"},
+		{"A1", "1", "1"},
+	}
+	for _, table := range []*Table[int64]{
+		NewTableOf[int64](overflows, false).Row(0).Values(1, Sum, "%d"),
+		NewTableOf[int64](overflows, false).Row(0).ComputedValues("S", DataRefs([]int{1, 2}, Sum), SumOf[int64], "%d"),
+		NewTableOf[int64](overflows, false).Row(0).ComputedValues("W", []DataRef{Weighted(1, 2)}, nil, "%d"),
+	} {
+		err = table.Generate()
+		if !errors.Is(err, ErrIntegerOverflow) {
+			t.Fatalf("expected integer overflow, got %v", err)
+		}
+	}
+	err = NewTableOf[uint64]([][]interface{}{{"A1", "18446744073709551615"}, {"A1", "1"}}, false).Row(0).Values(1, Sum, "%d").Generate()
+	if !errors.Is(err, ErrIntegerOverflow) {
+		t.Fatalf("expected integer overflow, got %v", err)
+	}
+}
+
+func TestEmptyValues(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", ""},
+		{"A1", "B1", "4"},
+		{"A1", "B2", ""},
+		{"A2", "B1", "2"},
+	}
+	table := NewTable(rawData, false).
+		ComputedRow([]int{0}, nil, nil, AlphaSort).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		ComputedValues("All", []DataRef{Ref(2, Count).OnEmpty(EmptyAsZero)}, nil, Digits(0)).
+		Values(2, Count, Digits(0)).
+		Values(2, Average, Digits(1)).
+		Values(2, Max, Digits(0)).
+		EmptyValues(SkipEmpty).
+		AbsentAs("-")
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := ";B1;B2;Total\n" +
+		"A1;[ 2, 1, 4.0, 4 ];[ 1, 0, -, - ];[ 3, 1, 4.0, 4 ]\n" +
+		"A2;[ 1, 1, 2.0, 2 ];-;[ 1, 1, 2.0, 2 ]\n" +
+		"Total;[ 3, 2, 3.0, 4 ];[ 1, 0, -, - ];[ 4, 2, 3.0, 4 ]\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+	err = NewTable(rawData, false).
+		Row(0).
+		Column(1).
+		Values(2, Sum, Digits(0)).
+		EmptyValues(FailOnEmpty).
+		Generate()
+	if !errors.Is(err, ErrEmptyValue) {
+		t.Fatalf("expected empty value error, got %v", err)
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "V"},
+		{"A1", "B1", "4"},
+		{"A1", "B1", "x"},
+		{"A1", "B2", 2},
+		{"A2", "B1", "y"},
+	}
+	err := NewTable(rawData, true).
+		Row(0).
+		Column(1).
+		Values(2, Sum, Digits(0)).
+		Generate()
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) {
+		t.Fatalf("expected record error, got %v", err)
+	}
+	if recordErr.Row != 2 || recordErr.Column != 2 || recordErr.Value != "x" {
+		t.Fatalf("unexpected record error %+v", recordErr)
+	}
+	table := NewTable(rawData, true).
+		Row(0).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0)).
+		Lenient(1)
+	err = table.Generate()
+	if !errors.As(err, &recordErr) || recordErr.Row != 4 {
+		t.Fatalf("expected record error on record 4, got %v", err)
+	}
+	table = NewTable(rawData, true).
+		Row(0).
+		ComputedColumn([]int{1}, nil, nil, AlphaSort).
+		Values(2, Sum, Digits(0)).
+		Lenient(2)
+	err = table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(table.RecordErrors()) != 2 {
+		t.Fatalf("len(table.RecordErrors())=%d!=2", len(table.RecordErrors()))
+	}
+	expected := ";B1;B2;Total\nA1;4;2;6\nTotal;4;2;6\n"
+	if table.ToCSV() != expected {
+		t.Fatalf("table.ToCSV()=%q!=%q", table.ToCSV(), expected)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A1", "B1", ""},
+		{"A1", "B1", "x"},
+	}
+	var buffer bytes.Buffer
+	err := NewTable(rawData, false).
+		Row(0).
+		Column(1).
+		Values(2, Sum, Digits(0)).
+		Lenient(1).
+		Generate(WithLogger(slog.New(slog.NewJSONHandler(&buffer, nil))))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	logs := buffer.String()
+	if !strings.Contains(logs, `"msg":"found record with empty value","record":0,"row":["A1"],"column":["B1"]`) {
+		t.Fatalf("missing empty value warning in %s", logs)
+	}
+	if !strings.Contains(logs, `"msg":"skipped bad record","record":1,"column":2`) {
+		t.Fatalf("missing skipped record warning in %s", logs)
+	}
+}
+
+func TestStats(t *testing.T) {
+	rawData := [][]interface{}{
+		{"A", "B", "C", "V"},
+		{"A1", "B1", "C1", "4"},
+		{"A1", "B2", "C2", ""},
+		{"A2", "B1", "C1", "x"},
+		{"A3", "B1", "C1", "1"},
+		{"A1", "B3", "C1", "1"},
+	}
+	table := NewTable(rawData, true).
+		Row(0).
+		ComputedRow([]int{2}, NotIn([]string{"C2"}), nil, nil).
+		Column(1).
+		Values(3, Sum, Digits(0)).
+		Filter(0, NotIn([]string{"A3"})).
+		Lenient(1)
+	err := table.Generate()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	stats := table.Stats()
+	if stats.Records != 5 || stats.Generated != 2 || stats.Skipped != 1 {
+		t.Fatalf("unexpected records stats %+v", stats)
+	}
+	if stats.FilteredByIndex[0] != 1 || stats.FilteredBySeries["C"] != 1 {
+		t.Fatalf("unexpected filter stats %+v", stats)
+	}
+	if len(stats.RowLabels) != 2 || stats.RowLabels[0] != 1 || stats.RowLabels[1] != 1 || stats.ColumnLabels[0] != 2 {
+		t.Fatalf("unexpected labels stats %+v", stats)
+	}
+	if stats.Cells != 9 {
+		t.Fatalf("stats.Cells=%d!=9", stats.Cells)
+	}
+}